@@ -0,0 +1,85 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"sort"
+)
+
+// Errors returned by Subcommand and RunCommand.
+var (
+	ErrNoCommand      = errors.New("missing command")
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+// Subcommand splits args, as returned by GetOpt and friends, into
+// the name of a subcommand, like "build" in "prog -v build -o x",
+// and the arguments following it.  If args is empty, ErrNoCommand
+// is returned; if it starts with a flag, the error is a FlagError
+// wrapping ErrNoCommand.
+func Subcommand(args []string) (name string, rest []string, err error) {
+	if len(args) == 0 {
+		return "", nil, ErrNoCommand
+	}
+	if len(args[0]) > 1 && args[0][0] == '-' {
+		return "", nil, newError(0, "", args[0], ErrNoCommand)
+	}
+	return args[0], args[1:], nil
+}
+
+// Command describes a subcommand for RunCommand.
+type Command struct {
+	Vars []Var                     // flags of the subcommand
+	Run  func(args []string) error // called with the remaining arguments
+}
+
+// Commands maps subcommand names to Commands.
+type Commands map[string]Command
+
+// RunCommand finds the subcommand named by the first element of args,
+// as described under Subcommand, parses its flags from the following
+// arguments like GetOptLong, and calls its Run function with the
+// remaining arguments, returning the error it returns.  A typical
+// program parses the global flags first:
+//
+//	args, err := conf.GetOptLong(globalVars)
+//	if err == nil {
+//		err = conf.RunCommand(commands, args)
+//	}
+//
+// With Options.ResponseFiles, response files are expanded by the
+// global pass only, so "@@name" reaches the subcommand as "@name".
+func RunCommand(cmds Commands, args []string) error {
+	return defaultOptions.RunCommand(cmds, args)
+}
+
+// RunCommand is like the package-level RunCommand, but modified by o.
+func (o *Options) RunCommand(cmds Commands, args []string) error {
+	name, args, err := Subcommand(args)
+	if err != nil {
+		return err
+	}
+	c, ok := cmds[name]
+	if !ok {
+		names := make([]string, 0, len(cmds))
+		for n := range cmds {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return newError(0, "", name,
+			withSuggestion(ErrUnknownCommand, suggest(name, names)))
+	}
+	// args have already been expanded by the global pass
+	opt := *o
+	opt.ResponseFiles = false
+	if args, err = opt.getOpt(args, c.Vars, gnuLong); err != nil {
+		return err
+	}
+	return c.Run(args)
+}
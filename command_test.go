@@ -0,0 +1,109 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var subcommandTests = []struct {
+	args []string
+	name string
+	rest []string
+	err  error
+}{
+	{[]string{"build", "-o", "x"}, "build", []string{"-o", "x"}, nil},
+	{[]string{"test"}, "test", []string{}, nil},
+	{[]string{"-", "x"}, "-", []string{"x"}, nil},
+	{nil, "", nil, ErrNoCommand},
+	{[]string{"-v", "build"}, "", nil, ErrNoCommand},
+}
+
+func TestSubcommand(t *testing.T) {
+	for _, test := range subcommandTests {
+		name, rest, err := Subcommand(test.args)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%q: got error %v, want %v", test.args, err, test.err)
+		} else if name != test.name || !reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("%q: got %q, %q, want %q, %q", test.args,
+				name, rest, test.name, test.rest)
+		}
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	var (
+		verbose, force bool
+		out            string
+		ran            string
+		got            []string
+	)
+	global := []Var{{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&verbose)}}
+	run := func(name string) func([]string) error {
+		return func(args []string) error {
+			ran, got = name, args
+			return nil
+		}
+	}
+	cmds := Commands{
+		"build": {
+			Vars: []Var{{Flag: 'o', Name: "out", Val: (*StringValue)(&out)}},
+			Run:  run("build"),
+		},
+		"clean": {
+			Vars: []Var{{Flag: 'f', Kind: NoArg, Val: (*BoolValue)(&force)}},
+			Run:  run("clean"),
+		},
+	}
+	argv := []string{"-v", "build", "--out", "x", "src"}
+	args, err := defaultOptions.getOpt(argv, global, gnuLong)
+	if err == nil {
+		err = RunCommand(cmds, args)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verbose || ran != "build" || out != "x" || force ||
+		!reflect.DeepEqual(got, []string{"src"}) {
+		t.Errorf("got %v, %q, %q, %v, %q", verbose, ran, out, force, got)
+	}
+
+	err = RunCommand(cmds, []string{"clean", "-v"})
+	if !errors.Is(err, ErrIllegalOption) {
+		t.Errorf("global flag after command: got %v", err)
+	}
+	err = RunCommand(cmds, []string{"biuld"})
+	if !errors.Is(err, ErrUnknownCommand) {
+		t.Errorf("unknown command: got %v", err)
+	}
+	err = RunCommand(cmds, nil)
+	if !errors.Is(err, ErrNoCommand) {
+		t.Errorf("no command: got %v", err)
+	}
+}
+
+func TestRunCommandResponseFiles(t *testing.T) {
+	var got []string
+	cmds := Commands{"add": {Run: func(args []string) error {
+		got = args
+		return nil
+	}}}
+	o := &Options{ResponseFiles: true}
+	args, err := o.getOpt([]string{"add", "@@literal"}, nil, gnuLong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.RunCommand(cmds, args); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"@literal"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
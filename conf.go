@@ -9,13 +9,19 @@ package conf
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Value is the interface to the value pointed to by Var.
@@ -27,6 +33,33 @@ type Value interface {
 	Set(string) error
 }
 
+// ValueString returns the result of v's String method and true,
+// or "" and false if v has no String method.
+func ValueString(v Value) (string, bool) {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String(), true
+	}
+	return "", false
+}
+
+// RawSetter is implemented by Values that need the value as it
+// appears in the configuration file, e.g., to report errors with
+// the original spelling.  When setting a Var from the file, Parse
+// calls SetRaw instead of Set, with the value as it appears in the
+// file, possibly quoted, and as it would be passed to Set.  Defaults
+// and command line arguments are still passed to Set.
+type RawSetter interface {
+	SetRaw(raw, unquoted string) error
+}
+
+// SetterContext is implemented by Values whose Set may take long,
+// e.g., resolving host names or reading files.  When setting a Var
+// from the file, Parse calls SetContext instead of Set, passing the
+// context given to ParseContext, or context.Background().
+type SetterContext interface {
+	SetContext(ctx context.Context, s string) error
+}
+
 // StringValue represents a configuration variable's string value.
 type StringValue string
 
@@ -58,7 +91,7 @@ func (v *BoolValue) Set(s string) error {
 	case strInList(s, []string{"1", "true", "t", "on", "yes", "y", "enabled"}):
 		*v = true
 	default:
-		return errSyntax
+		return ErrSyntax
 	}
 	return nil
 }
@@ -67,7 +100,8 @@ func (v *BoolValue) String() string { return strconv.FormatBool(bool(*v)) }
 
 // Int64Value represents a configuration variable's int64 value.
 // Numeric values can be given as decimal, octal or hexadecimal
-// in the usual C/Go manner (255 == 0377 == 0xff).
+// in the usual C/Go manner (255 == 0377 == 0xff).  Beware that
+// zero-padded numbers are octal (010 == 8); see DecimalInt64Value.
 type Int64Value int64
 
 func (v *Int64Value) Set(s string) error {
@@ -82,12 +116,52 @@ func (v *Int64Value) Set(s string) error {
 
 func (v *Int64Value) String() string { return strconv.FormatInt(int64(*v), 10) }
 
+// IntValue represents a configuration variable's int value, parsed
+// like Int64Value.  Values not fitting in an int are rejected with
+// strconv.ErrRange.
+type IntValue int
+
+func (v *IntValue) Set(s string) error {
+	n, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		return err.(*strconv.NumError).Err
+	}
+	*v = IntValue(n)
+	return nil
+}
+
+func (v *IntValue) String() string { return strconv.Itoa(int(*v)) }
+
+// DecimalInt64Value represents a configuration variable's int64 value
+// given in decimal.  Unlike with Int64Value, leading zeros don't mean
+// octal, so zero-padded numbers work as expected (010 == 10).
+type DecimalInt64Value int64
+
+func (v *DecimalInt64Value) Set(s string) error {
+	u, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err.(*strconv.NumError).Err
+	}
+	*v = DecimalInt64Value(u)
+	return nil
+}
+
+func (v *DecimalInt64Value) String() string {
+	return strconv.FormatInt(int64(*v), 10)
+}
+
 // Uint64Value represents a configuration variable's uint64 value.
 // Numeric values can be given as decimal, octal or hexadecimal
-// in the usual C/Go manner (255 == 0377 == 0xff).
+// in the usual C/Go manner (255 == 0377 == 0xff).  Negative values
+// are rejected as such, and values above 2^64-1 with strconv.ErrRange.
 type Uint64Value uint64
 
+var errNegative = errors.New("negative value not allowed")
+
 func (v *Uint64Value) Set(s string) error {
+	if strings.HasPrefix(s, "-") {
+		return errNegative
+	}
 	u, err := strconv.ParseUint(s, 0, 64)
 	if err != nil {
 		// strip fluff from strconf.ParseUint
@@ -99,6 +173,78 @@ func (v *Uint64Value) Set(s string) error {
 
 func (v *Uint64Value) String() string { return strconv.FormatUint(uint64(*v), 10) }
 
+// Resetter is implemented by Values accumulating repeated settings,
+// like StringSliceValue.  Reset discards the accumulated settings.
+// It's called before the first setting of a Var in every pass, i.e.,
+// every call to Parse or GetOpt, and before setting the Default, so
+// that settings accumulate within a pass, but reparsing, e.g., with
+// Reload, doesn't duplicate them.
+//
+// Values of this package holding pointers to their variables, like
+// EnumValue, implement Resetter as well, setting the variable to its
+// zero value.  They're not Reset before setting, as Set replaces the
+// value anyway, but Reload resets them if their setting is removed.
+type Resetter interface {
+	Reset()
+}
+
+// multiValue is implemented by Values accumulating repeated settings.
+type multiValue interface {
+	Value
+	multi()
+}
+
+// StringSliceValue represents a configuration variable's list of
+// string values.  Each call to Set appends to the list, so a Var
+// having a StringSliceValue may be set more than once, both in the
+// configuration file and on the command line, like "-I dir1 -I dir2".
+// If such a Var is set on the command line, all its settings in the
+// configuration file are ignored.  The list is emptied before the
+// first setting in every pass, as described under Resetter.
+type StringSliceValue []string
+
+func (v *StringSliceValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+func (v *StringSliceValue) String() string { return strings.Join(*v, ",") }
+
+func (v *StringSliceValue) multi() {}
+
+func (v *StringSliceValue) Reset() { *v = nil }
+
+// CountValue represents a configuration variable's counter value,
+// typically bound to a NoArg flag, as in -v, -vv, -v -v -v for
+// increasing verbosity.  Set increments the counter when called with
+// "true" (as for NoArg flags), resets it to zero with "false" (as for
+// the '+' prefix in GetOptLongOnly), and otherwise sets it to the
+// given number.  Like StringSliceValue, a Var having a CountValue may
+// be set more than once.
+type CountValue int
+
+func (v *CountValue) Set(s string) error {
+	switch s {
+	case "true":
+		*v++
+	case "false":
+		*v = 0
+	default:
+		n, err := strconv.ParseInt(s, 0, 0)
+		if err != nil {
+			return err.(*strconv.NumError).Err
+		}
+		*v = CountValue(n)
+	}
+	return nil
+}
+
+func (v *CountValue) String() string { return strconv.Itoa(int(*v)) }
+
+func (v *CountValue) multi() {}
+
+func (v *CountValue) Reset() { *v = 0 }
+
 type funcValue struct {
 	f func(string) error
 }
@@ -112,10 +258,65 @@ func FuncValue(f func(string) error) Value {
 	return funcValue{f}
 }
 
+type textValue struct {
+	u encoding.TextUnmarshaler
+}
+
+func (v textValue) Set(s string) error {
+	return v.u.UnmarshalText([]byte(s))
+}
+
+func (v textValue) String() string {
+	if m, ok := v.u.(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return ""
+}
+
+// TextValue returns a Value whose Set method calls u.UnmarshalText,
+// e.g., TextValue(&t) for a time.Time t.  Its String method calls
+// MarshalText if u implements encoding.TextMarshaler, and returns
+// the empty string otherwise.
+func TextValue(u encoding.TextUnmarshaler) Value {
+	return textValue{u}
+}
+
+// FromFlagValue returns v as a Value.  Every flag.Value is a Value,
+// so this is a mere conversion, spelled out for readability.
+func FromFlagValue(v flag.Value) Value {
+	return v
+}
+
+type flagValue struct {
+	Value
+}
+
+func (v flagValue) String() string {
+	s, _ := ValueString(v.Value)
+	return s
+}
+
+// ToFlagValue returns v as a flag.Value.  If v has no String method,
+// the returned String method returns the empty string.
+//
+// Together with FromFlagValue, this lets a program register the same
+// Values both with the flag package and in vars, e.g.:
+//
+//	flag.Var(conf.ToFlagValue(vars[i].Val), vars[i].Name, vars[i].Help)
+func ToFlagValue(v Value) flag.Value {
+	if f, ok := v.(flag.Value); ok {
+		return f
+	}
+	return flagValue{v}
+}
+
 const (
-	HasArg  = iota // flag requires arguments
-	NoArg          // boolean flag with no arguments
-	LineArg        // flag ends processing
+	HasArg      = iota // flag requires arguments
+	NoArg              // boolean flag with no arguments
+	LineArg            // flag ends processing
+	OptionalArg        // flag may have an argument attached
 )
 
 // Var describes a configuration variable / command line flag
@@ -125,45 +326,355 @@ type Var struct {
 	Flag     rune   // short option
 	Name     string // name of configuration variable / long option
 	Val      Value  // Value to set
-	Kind     int    // HasArg / NoArg / LineArg
+	Kind     int    // HasArg / NoArg / LineArg / OptionalArg
 	Required bool   // variable is required to be set in conf file
+	Help     string // description for Usage
+	Default  string // value set by Parse before reading the file
 	set      bool   // has been set from conf file
 	flagSet  bool   // has been set from command line
+
+	// Check, if not nil, is called after every successful Set with
+	// the same argument, so that it can validate the value, e.g.,
+	// check that a port number set by Int64Value is in range.
+	// Its error is reported like one returned by Set.
+	Check func(string) error
+
+	// AllowMultiple allows setting the variable more than once,
+	// calling Set for every setting.  Vars whose Value accumulates
+	// settings, like StringSliceValue, allow it regardless.
+	AllowMultiple bool
+
+	// NArgs is the number of command line arguments taken by a
+	// HasArg flag, as in "--point 3 4".  The first one may be
+	// attached to the flag as usual, the rest are the following
+	// arguments.  Set is called once per argument, so the Value
+	// has to accumulate them, like IntSliceValue.  Zero means 1.
+	NArgs int
+
+	// Hidden omits the Var from Usage, e.g., for deprecated or
+	// internal options.  It doesn't affect parsing.
+	Hidden bool
+
+	// Deprecated lists former names of the variable, which are
+	// still accepted in configuration files, but with a warning
+	// passed to Options.Warn, e.g., after renaming a setting.
+	Deprecated []string
+
+	// Aliases and AliasFlags are additional names and flags the
+	// variable answers to, in configuration files and on the
+	// command line, e.g., "out" for "output".  Setting the
+	// variable by any of them counts as setting it.
+	Aliases    []string
+	AliasFlags []rune
+}
+
+// WasSet reports whether v has been set from configuration file or
+// command line since the last Reset.  Setting v to its Default doesn't
+// count.  A Var set from both command line and configuration file,
+// whether by Flag or by Name, is only set to the command line value
+// (see Parse), but has been set nonetheless.
+func WasSet(v *Var) bool {
+	return v.set || v.flagSet
+}
+
+// Reset clears the record of vars having been set from configuration
+// file or command line, so that the same vars can be used for parsing
+// again, e.g., when reloading the configuration file.  It doesn't
+// touch the Values, which retain whatever was set before.
+func Reset(vars []Var) {
+	for i := range vars {
+		vars[i].set, vars[i].flagSet = false, false
+	}
+}
+
+// resetVal calls Reset if v.Val is a Resetter, except for Values of
+// this package replacing rather than accumulating settings, like
+// EnumValue, so that a failed Set keeps the previous value.
+func (v *Var) resetVal() {
+	val := v.Val
+	if o, ok := val.(*OptionalValue); ok {
+		val = o.Value
+	}
+	if _, ok := val.(cloner); ok {
+		if _, ok := val.(multiValue); !ok {
+			return
+		}
+	}
+	if r, ok := v.Val.(Resetter); ok {
+		r.Reset()
+	}
+}
+
+// multiple reports whether v may be set more than once.
+func (v *Var) multiple() bool {
+	val := v.Val
+	if o, ok := val.(*OptionalValue); ok {
+		val = o.Value
+	}
+	_, ok := val.(multiValue)
+	return ok || v.AllowMultiple
+}
+
+// names returns v's Name and Aliases.
+func (v *Var) names() []string {
+	return append([]string{v.Name}, v.Aliases...)
+}
+
+// flags returns v's Flag and AliasFlags.
+func (v *Var) flags() []rune {
+	return append([]rune{v.Flag}, v.AliasFlags...)
+}
+
+// hasName reports whether name is v's Name or one of its Aliases.
+func (v *Var) hasName(name string) bool {
+	for _, n := range v.names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFlag reports whether flag is v's Flag or one of its AliasFlags.
+func (v *Var) hasFlag(flag rune) bool {
+	for _, f := range v.flags() {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// BoolVar returns a Var with the given Flag, Name and Kind, setting
+// target with BoolValue.  It saves spelling out the conversion:
+//
+//	conf.BoolVar('v', "verbose", &verbose, conf.NoArg)
+//
+// is the same as:
+//
+//	conf.Var{Flag: 'v', Name: "verbose",
+//		Val: (*conf.BoolValue)(&verbose), Kind: conf.NoArg}
+//
+// Other fields can be set on the result.
+func BoolVar(flag rune, name string, target *bool, kind int) Var {
+	return Var{Flag: flag, Name: name, Val: (*BoolValue)(target), Kind: kind}
+}
+
+// StringVar is like BoolVar, but sets target with StringValue.
+func StringVar(flag rune, name string, target *string, kind int) Var {
+	return Var{Flag: flag, Name: name, Val: (*StringValue)(target), Kind: kind}
+}
+
+// Int64Var is like BoolVar, but sets target with Int64Value.
+func Int64Var(flag rune, name string, target *int64, kind int) Var {
+	return Var{Flag: flag, Name: name, Val: (*Int64Value)(target), Kind: kind}
+}
+
+// Func is like BoolVar, but calls f with the value, like FuncValue.
+func Func(flag rune, name string, f func(string) error, kind int) Var {
+	return Var{Flag: flag, Name: name, Val: FuncValue(f), Kind: kind}
+}
+
+// Options modifies the way configuration files and command line
+// arguments are parsed.  The zero value gives the behaviour of the
+// package-level functions.
+type Options struct {
+	// Expand enables expansion of environment variables in values.
+	// After unquoting, ${NAME} and $NAME are replaced with the value
+	// of the environment variable NAME, or the empty string if it's
+	// undefined, and $$ is replaced with a single $.  Names in $NAME
+	// consist of ASCII letters, digits and underscores, and don't
+	// start with a digit.  Any other $, as in "cost $5" or "${}",
+	// is left alone.  References are not nested: in "${A${B}}", the
+	// variable name is "A${B".
+	Expand bool
+
+	// Env, if not nil, is used instead of the process environment
+	// by Expand and by the @include-if directive (see ParseFile).
+	Env map[string]string
+
+	// TrimSpace makes Parse trim leading and trailing whitespace
+	// (as defined by Unicode) from values after unquoting and
+	// expansion, so that "  x  " sets a variable to "x".
+	TrimSpace bool
+
+	// Abbrev allows abbreviating long options on the command line
+	// (in GetOptLong and GetOptLongOnly) to any prefix that is not
+	// a prefix of another long option.  Exact matches always win,
+	// so if there are options "verb" and "verbose", "--verb" means
+	// the former.
+	Abbrev bool
+
+	// Permute makes command line processing continue past non-flag
+	// arguments, glibc style, so that "prog file1 -v file2" sets
+	// the 'v' flag.  Non-flag arguments are returned (and kept in
+	// Args) in their original order, followed by any arguments
+	// after "--" or after a LineArg flag, where processing stops.
+	Permute bool
+
+	// ResponseFiles enables response files on the command line:
+	// an argument "@name" is replaced by the arguments read from
+	// the file name, separated by whitespace.  Within arguments,
+	// single quotes, double quotes and backslashes work like in
+	// the shell, except that no expansion is done.  Response files
+	// may refer to other response files, with names relative to
+	// the current directory.  An argument starting with "@@" is
+	// replaced by itself without the first '@'.  Response files
+	// are expanded before processing flags, so "@name" after "--"
+	// is expanded as well.
+	ResponseFiles bool
+
+	// LastFlagWins allows NoArg flags to be given more than once
+	// on the command line, each occurrence overriding the previous
+	// ones, so that "--flag --no-flag" or "-b +b" (GetOptLongOnly)
+	// leave the flag false.  Other flags still may only be given
+	// once, unless their Values accumulate.
+	LastFlagWins bool
+
+	// MaxLine is the maximum length of a line in configuration
+	// files, in bytes.  Longer lines are errors.  If zero, the
+	// limit is 4096 bytes.
+	MaxLine int
+
+	// HashInValues allows plain values to contain '#', which
+	// otherwise starts a comment, so that "color = #ff0000" sets
+	// color to "#ff0000".  Comments following values must then be
+	// separated from them by whitespace: in "a = b#c # comment",
+	// the value is "b#c".
+	HashInValues bool
+
+	// SemicolonComments makes ';' start a comment, like '#', at the
+	// start of a line or after whitespace, as in INI files.  A ';'
+	// inside a plain value remains part of it: in "a = b;c ; comment",
+	// the value is "b;c".
+	SemicolonComments bool
+
+	// EqualsInValues allows plain values to contain '=', so that
+	// "q = a=b&c=d" sets q to "a=b&c=d" without quoting it.  The
+	// first '=' on the line still separates the identifier from
+	// the value.
+	EqualsInValues bool
+
+	// UnicodeIdents allows identifiers and section names to contain
+	// Unicode letters besides ASCII ones, as in "café = x".  They
+	// may start with a letter, dash or underscore, and continue with
+	// those, combining marks and ASCII digits.  Identifiers are
+	// compared with Var Names byte by byte, without normalisation.
+	UnicodeIdents bool
+
+	// BareFlags allows lines consisting of an identifier alone,
+	// like "verbose", which set NoArg Vars to "true", as on the
+	// command line.  For other Vars, they are errors wrapping
+	// ErrNoValue.
+	BareFlags bool
+
+	// Unknown, if not nil, is called by Parse for settings of
+	// unknown variables instead of failing, with the identifier
+	// (prefixed with the section name, if any) and the value as
+	// it would be passed to Value.Set.  If it returns nil, the
+	// setting is ignored; otherwise parsing stops, and the error
+	// is returned wrapped in ParseError.
+	Unknown func(ident, value string) error
+
+	// OnSet, if not nil, is called by Parse after each variable
+	// is set from the file, in file order, with the identifier
+	// (prefixed with the section name, if any), the value as it
+	// appears in the file, possibly quoted, and the line number.
+	// It's not called for Vars set from command line, as these
+	// are not set from the file.  If OnSet returns an error,
+	// parsing stops, and the error is returned wrapped in
+	// ParseError.
+	OnSet func(ident, raw string, line int) error
+
+	// Warn, if not nil, is called by Parse with warnings that don't
+	// stop parsing, like "conf:3: option 'foo' is deprecated, use
+	// 'bar'" for settings using one of the Deprecated names of a Var.
+	Warn func(msg string)
 }
 
+// defaultMaxLine is the default maximum line length.
+const defaultMaxLine = 4096
+
+// defaultOptions are used by the package-level functions.
+var defaultOptions Options
+
 type parser struct {
 	r     *bufio.Reader
+	max   int // maximum line length
 	file  string
-	line  int
+	line  int // number of first physical line of current line
+	phys  int // number of physical lines read
 	ident string
 	value string
+	text  string // current line, after joining continued lines
+	col   int    // column of current token in text, or 0
+	vcol  int    // column of current value in text, or 0
 	vars  []Var
+	opt   *Options
+	sect  string          // current section name
+	path  string          // absolute path of file, for includes
+	open  map[string]bool // absolute paths of files being parsed
+	ctx   context.Context
+	ini   bool // INI-style values (see ParseINI)
+
+	record bool     // record physical lines for ReadDocument
+	lines  []string // physical lines of current line, if recorded
 }
 
+// Errors returned by Parse and related functions in ParseError.
+// They may be wrapped with more detail; use errors.Is to test for them.
 var (
-	errSyntax      = errors.New("syntax error")
-	errLineTooLong = errors.New("line too long")
-	errReqNotSet   = errors.New("required but not set")
-	errAlreadyDef  = errors.New("already defined")
-	errUnknownVar  = errors.New("unknown variable")
+	ErrSyntax      = errors.New("syntax error")
+	ErrLineTooLong = errors.New("line too long")
+	ErrReqNotSet   = errors.New("required but not set")
+	ErrAlreadyDef  = errors.New("already defined")
+	ErrUnknownVar  = errors.New("unknown variable")
+	ErrReqDefault  = errors.New("required variable has default")
+	ErrUnknownDir  = errors.New("unknown directive")
+	ErrNoInclude   = errors.New("include not supported outside ParseFile")
+	ErrIncludeLoop = errors.New("include loop")
+	ErrUnclosed    = errors.New("unterminated multi-line value")
+	ErrNoAppend    = errors.New("appending to variable not accumulating values")
+	ErrNoValue     = errors.New("value required")
 )
 
+// ErrStopParsing may be returned by Value.Set to make Parse stop
+// reading the configuration file, as if it ended after the current
+// setting, which is still considered set.  Parse then checks that
+// Required Vars have been set, and returns nil if they have.  This
+// includes any files being included and, for ParseAll, any files
+// following.  ErrStopParsing is never returned by Parse.
+var ErrStopParsing = errors.New("stop parsing")
+
+// ErrTrailing is returned in ParseError for anything but a comment
+// following the value of a setting, as in "port = 80 81".  The
+// ParseError's Value is the rest of the line, starting with the
+// offending text.  ErrTrailing wraps ErrSyntax.
+var ErrTrailing = fmt.Errorf("%w: unexpected text after value", ErrSyntax)
+
+var errStrayCR = fmt.Errorf("%w: carriage return not followed by line feed",
+	ErrSyntax)
+
 // ParseError represents a configuration file parsing error.
 type ParseError struct {
-	File  string // filename or "stdin"
-	Line  int    // line number or 0
-	Ident string // identifier or ""
-	Value string // value as appears in input, possibly quoted; or ""
-	Err   error  // error
+	File   string // filename or "stdin"
+	Line   int    // line number or 0
+	Column int    // column (byte offset plus one) within Line, or 0
+	Ident  string // identifier or ""
+	Value  string // value as appears in input, possibly quoted; or ""
+	Err    error  // error
 }
 
 // Error prints ParseError as follows:
-//     File:[Line:][ Ident:] Err
+//     File:[Line:[Column:]][ Ident:] Err
 // Value never gets printed.
 func (p *ParseError) Error() string {
 	var line, ident string
 	if p.Line != 0 {
 		line = fmt.Sprintf("%d:", p.Line)
+		if p.Column != 0 {
+			line += fmt.Sprintf("%d:", p.Column)
+		}
 	}
 	if p.Ident != "" {
 		ident = fmt.Sprintf(" %s:", p.Ident)
@@ -171,15 +682,32 @@ func (p *ParseError) Error() string {
 	return fmt.Sprintf("%s:%s%s %s\n", p.File, line, ident, p.Err)
 }
 
+// Unwrap returns p.Err, so that errors.Is and errors.As can examine it.
+func (p *ParseError) Unwrap() error { return p.Err }
+
 // newError creates ParseError from s
 func (p *parser) newError(e error) *ParseError {
-	return &ParseError{p.file, p.line, p.ident, p.value, e}
+	return &ParseError{p.file, p.line, p.col, p.ident, p.value, e}
+}
+
+// setCol sets the column of the next error to the start of rest,
+// which must be a suffix of the current line.
+func (p *parser) setCol(rest string) {
+	if strings.HasSuffix(p.text, rest) {
+		p.col = len(p.text) - len(rest) + 1
+	} else {
+		p.col = 0
+	}
 }
 
 // Regexps for tokens
 var (
 	identRE  = regexp.MustCompile(`^[-_a-zA-Z][-_a-zA-Z0-9]*`)
+	uidentRE = regexp.MustCompile(`^[-_\pL][-_\pL\pM0-9]*`) // Unicode ident
 	plainRE  = regexp.MustCompile(`^[^\pZ\pC"#'=\\]+`)
+	hashRE   = regexp.MustCompile(`^[^\pZ\pC"'=\\]+`) // plain with '#'
+	eqRE     = regexp.MustCompile(`^[^\pZ\pC"#'\\]+`) // plain with '='
+	hashEqRE = regexp.MustCompile(`^[^\pZ\pC"'\\]+`)  // plain with '#' and '='
 	quotedRE = regexp.MustCompile(`^"(?:[^\pC"\\]|\\[^\pC])*"`)
 )
 
@@ -187,52 +715,393 @@ func eatSpace(s string) string {
 	return strings.TrimLeftFunc(s, unicode.IsSpace)
 }
 
-func (p *parser) setValue(value string) error {
+// deprecated reports whether p.ident is one of the Deprecated names
+// of v, warning about it if so.
+func (p *parser) deprecated(v *Var) bool {
+	for _, name := range v.Deprecated {
+		if p.ident == name {
+			if p.opt.Warn != nil {
+				p.opt.Warn(fmt.Sprintf("%s:%d: option '%s' is "+
+					"deprecated, use '%s'", p.file, p.line, name, v.Name))
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) setValue(value string, add, bare bool) error {
 	for i := range p.vars {
 		v := &p.vars[i]
-		if p.ident == v.Name {
-			if v.set {
-				return p.newError(errAlreadyDef)
+		if v.hasName(p.ident) || p.deprecated(v) {
+			if add && !v.multiple() {
+				return p.newError(ErrNoAppend)
 			}
-			if !v.flagSet {
-				if err := v.Val.Set(value); err != nil {
-					return &ParseError{p.file, p.line,
-						p.ident, p.value, err}
-				}
+			if bare && v.Kind != NoArg {
+				return p.newError(ErrNoValue)
+			}
+			if v.set && !v.multiple() {
+				return p.newError(ErrAlreadyDef)
+			}
+			if v.flagSet {
+				v.set = true
+				return nil
+			}
+			if !v.set {
+				v.resetVal()
+			}
+			var err error
+			if c, ok := v.Val.(SetterContext); ok {
+				err = c.SetContext(p.ctx, value)
+			} else if r, ok := v.Val.(RawSetter); ok {
+				err = r.SetRaw(p.value, value)
+			} else {
+				err = v.Val.Set(value)
+			}
+			if err == nil && v.Check != nil {
+				err = v.Check(value)
+			}
+			if err == ErrStopParsing {
+				v.set = true
+				return err
+			}
+			if err != nil {
+				return &ParseError{p.file, p.line, p.vcol,
+					p.ident, p.value, err}
 			}
 			v.set = true
+			if p.opt.OnSet != nil {
+				if err := p.opt.OnSet(p.ident, p.value, p.line); err != nil {
+					return p.newError(err)
+				}
+			}
 			return nil
 		}
 	}
-	return p.newError(errUnknownVar)
+	if p.opt.Unknown != nil {
+		if err := p.opt.Unknown(p.ident, value); err != nil {
+			return p.newError(err)
+		}
+		return nil
+	}
+	var names []string
+	for i := range p.vars {
+		names = append(names, p.vars[i].names()...)
+	}
+	return p.newError(withSuggestion(ErrUnknownVar, suggest(p.ident, names)))
+}
+
+// setDefaults sets Vars not set from command line to their Defaults.
+func (p *parser) setDefaults() error {
+	for i := range p.vars {
+		v := &p.vars[i]
+		if v.Default == "" {
+			continue
+		}
+		if v.Required {
+			return &ParseError{p.file, 0, 0, v.Name, "", ErrReqDefault}
+		}
+		if v.flagSet {
+			continue
+		}
+		v.resetVal()
+		err := v.Val.Set(v.Default)
+		if err == nil && v.Check != nil {
+			err = v.Check(v.Default)
+		}
+		if err != nil {
+			return &ParseError{p.file, 0, 0, v.Name, v.Default, err}
+		}
+	}
+	return nil
+}
+
+// scanIdent scans an identifier at the start of line.
+func (p *parser) scanIdent(line string) string {
+	if p.opt.UnicodeIdents {
+		return uidentRE.FindString(line)
+	}
+	return identRE.FindString(line)
+}
+
+// scanValue scans a plain or quoted value at the start of line
+// and returns it as it appears in line and unquoted.
+func (p *parser) scanValue(line string) (raw, unquoted string, ok bool) {
+	re := plainRE
+	switch {
+	case p.opt.HashInValues && p.opt.EqualsInValues:
+		re = hashEqRE
+	case p.opt.HashInValues:
+		re = hashRE
+	case p.opt.EqualsInValues:
+		re = eqRE
+	}
+	if raw = re.FindString(line); raw != "" {
+		return raw, raw, true
+	}
+	raw = quotedRE.FindString(line)
+	unquoted, err := strconv.Unquote(raw)
+	return raw, unquoted, err == nil
 }
 
-func (p *parser) parseLine(line string) error {
+// comment reports whether line, following whitespace or
+// starting a line, is a comment.
+func (p *parser) comment(line string) bool {
+	return line[0] == '#' || p.opt.SemicolonComments && line[0] == ';'
+}
+
+// parseDirective parses a directive line after the '@'.
+func (p *parser) parseDirective(line string) (Element, error) {
+	name := identRE.FindString(line)
+	p.ident = "@" + name
+	if name == "" {
+		return nil, p.newError(ErrSyntax)
+	}
+	d := &Directive{Line: p.line, Name: name}
+	col := p.col
+	line = line[len(name):]
+	for {
+		rest := eatSpace(line)
+		if rest == "" || p.comment(rest) {
+			d.TrailingComment = rest
+			p.col = col
+			return d, nil
+		}
+		p.setCol(rest)
+		if len(rest) == len(line) {
+			// no space between tokens
+			return nil, p.newError(ErrSyntax)
+		}
+		raw, unquoted, ok := p.scanValue(rest)
+		p.value = raw
+		if !ok {
+			return nil, p.newError(ErrSyntax)
+		}
+		d.Raw = append(d.Raw, raw)
+		d.Args = append(d.Args, unquoted)
+		line = rest[len(raw):]
+	}
+}
+
+// parseSection parses a section header after the '['.
+func (p *parser) parseSection(line string) (Element, error) {
 	line = eatSpace(line)
-	if line == "" || line[0] == '#' {
-		return nil
+	name := p.scanIdent(line)
+	line = eatSpace(line[len(name):])
+	if line == "" || line[0] != ']' {
+		p.setCol(line)
+		return nil, p.newError(ErrSyntax)
 	}
-	p.ident = identRE.FindString(line)
+	line = eatSpace(line[1:])
+	if len(line) != 0 && !p.comment(line) {
+		p.setCol(line)
+		return nil, p.newError(ErrSyntax)
+	}
+	return &Section{p.line, name, line}, nil
+}
+
+// parseLine parses a line and returns the corresponding Element.
+func (p *parser) parseLine(line string) (Element, error) {
+	p.text = line
+	line = eatSpace(line)
+	p.setCol(line)
+	if line == "" {
+		return &Blank{p.line}, nil
+	} else if p.comment(line) {
+		return &Comment{p.line, line}, nil
+	}
+	switch line[0] {
+	case '@':
+		return p.parseDirective(line[1:])
+	case '[':
+		return p.parseSection(line[1:])
+	}
+	p.ident = p.scanIdent(line)
 	line = eatSpace(line[len(p.ident):])
+	add := strings.HasPrefix(line, "+=")
+	if add {
+		line = line[1:]
+	}
+	if p.opt.BareFlags && p.ident != "" && !add &&
+		(line == "" || p.comment(line)) {
+		p.vcol = p.col
+		return &Assignment{p.line, p.ident, "", "true", false, true,
+			line}, nil
+	}
 	if p.ident == "" || line == "" || line[0] != '=' {
-		return p.newError(errSyntax)
+		if p.ident != "" {
+			p.setCol(line)
+		}
+		return nil, p.newError(ErrSyntax)
 	}
+	col := p.col
 	line = eatSpace(line[1:])
-	p.value = plainRE.FindString(line)
-	unquoted := p.value
-	if p.value == "" {
-		p.value = quotedRE.FindString(line)
+	p.setCol(line)
+	p.vcol = p.col
+	var (
+		unquoted string
+		ok       bool
+	)
+	if strings.HasPrefix(line, `"""`) {
 		var err error
-		unquoted, err = strconv.Unquote(p.value)
-		if err != nil {
-			return p.newError(errSyntax)
+		if p.value, unquoted, line, err = p.scanMultiline(line); err != nil {
+			return nil, err
+		}
+	} else if p.ini && !strings.HasPrefix(line, `"`) {
+		p.value = scanINIValue(line)
+		unquoted, line = p.value, line[len(p.value):]
+	} else if p.value, unquoted, ok = p.scanValue(line); ok {
+		line = line[len(p.value):]
+	} else {
+		return nil, p.newError(ErrSyntax)
+	}
+	line = eatSpace(line)
+	if len(line) != 0 && !p.comment(line) {
+		p.setCol(line)
+		p.value = line
+		tok := line
+		if i := strings.IndexFunc(tok, unicode.IsSpace); i != -1 {
+			tok = tok[:i]
 		}
+		return nil, p.newError(fmt.Errorf("%w: %s", ErrTrailing, tok))
+	}
+	p.col = col
+	return &Assignment{p.line, p.ident, p.value, unquoted, add, false,
+		line}, nil
+}
+
+// newParser creates a parser reading from r.
+func newParser(r io.Reader, filename string, vars []Var, opt *Options) *parser {
+	p := &parser{file: filename, vars: vars, opt: opt,
+		ctx: context.Background()}
+	if p.file == "" {
+		p.file = "stdin"
+	}
+	p.max = opt.MaxLine
+	if p.max <= 0 {
+		p.max = defaultMaxLine
+	}
+	// room for CRLF, so that lines of p.max bytes fit
+	p.r = bufio.NewReaderSize(r, p.max+2)
+	return p
+}
+
+// valueStart reports whether a plain value would start after
+// before, i.e., before is an identifier followed by '=' or "+=",
+// so that a '#' (with HashInValues) or ';' following it is part
+// of the value, not a comment.
+func (p *parser) valueStart(before string) bool {
+	if p.ini {
+		// see scanINIValue
+		return false
+	}
+	before = eatSpace(before)
+	ident := p.scanIdent(before)
+	rest := strings.TrimSpace(before[len(ident):])
+	return ident != "" && (rest == "=" || rest == "+=")
+}
+
+// continued reports whether line ends with a backslash outside of
+// quoted values and comments.  Comments start like in parseLine:
+// '#' starts a comment anywhere, unless plain values may contain
+// it, in which case it, like ';' with SemicolonComments, only does
+// at the start of line or after whitespace, and not at the start
+// of a value.
+func (p *parser) continued(line string) bool {
+	hash := p.opt.HashInValues || p.ini
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			if quoted {
+				i++
+			} else if i == len(line)-1 {
+				return true
+			}
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '#' && !hash:
+			return false
+		case c == '#' || c == ';' && p.opt.SemicolonComments:
+			r, _ := utf8.DecodeLastRuneInString(line[:i])
+			if (i == 0 || unicode.IsSpace(r)) &&
+				!p.valueStart(line[:i]) {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// readLine reads the next physical line.
+func (p *parser) readLine() (string, error) {
+	p.phys++
+	buf, ispref, err := p.r.ReadLine()
+	if err != nil {
+		return "", err
+	} else if ispref || len(buf) > p.max {
+		p.line = p.phys
+		return "", p.newError(ErrLineTooLong)
+	} else if i := bytes.IndexByte(buf, '\r'); i != -1 {
+		// ReadLine strips CRLF, so any CR left is stray
+		p.line, p.col = p.phys, i+1
+		return "", p.newError(errStrayCR)
 	}
-	line = eatSpace(line[len(p.value):])
-	if len(line) != 0 && line[0] != '#' {
-		return p.newError(errSyntax)
+	if p.record {
+		p.lines = append(p.lines, string(buf))
 	}
-	return p.setValue(unquoted)
+	return string(buf), nil
+}
+
+// scanMultiline scans a triple-quoted value at the start of line,
+// reading more lines as needed.  It returns the value as it appears
+// in input and unquoted, and the rest of the last line.
+func (p *parser) scanMultiline(line string) (raw, unquoted, rest string, err error) {
+	raw = line
+	for {
+		if i := strings.Index(raw[3:], `"""`); i != -1 {
+			unquoted = strings.TrimPrefix(raw[3:i+3], "\n")
+			return raw[:i+6], unquoted, raw[i+6:], nil
+		}
+		line, err = p.readLine()
+		if err == io.EOF {
+			return "", "", "", p.newError(ErrUnclosed)
+		} else if err != nil {
+			return "", "", "", err
+		}
+		raw += "\n" + line
+	}
+}
+
+// next reads and parses the next line, joining continued
+// physical lines.  It returns io.EOF at end of input.
+func (p *parser) next() (Element, error) {
+	p.line = p.phys + 1
+	p.ident, p.value = "", ""
+	p.text, p.col, p.vcol = "", 0, 0
+	p.lines = nil
+	var line string
+	for {
+		buf, err := p.readLine()
+		if err == io.EOF && p.phys > p.line {
+			// backslash on last line
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if p.phys > p.line {
+			line += eatSpace(buf)
+		} else {
+			line = buf
+		}
+		if !p.continued(line) {
+			break
+		}
+		line = line[:len(line)-1]
+	}
+	return p.parseLine(line)
 }
 
 // Parse parses the configuration file from r according the description
@@ -242,8 +1111,10 @@ func (p *parser) parseLine(line string) error {
 // from the depths of io on actual real error.
 //
 // Parsing stops on the first error encountered.  Setting an unknown
-// variable, setting a variable more than once or omitting a Var whose
-// Required == true are errors.
+// variable, setting a variable more than once (unless AllowMultiple
+// is set or its Value is a StringSliceValue) or omitting a Var whose
+// Required == true are errors.  Appending to a variable with "+=" is
+// allowed only where setting it more than once is.
 //
 // When parsing, the value gets unquoted if needed and the Var
 // corresponding to the identifier is found.  Then the Set() method
@@ -251,37 +1122,288 @@ func (p *parser) parseLine(line string) error {
 // should create your own Value type and return an error from Set()
 // on invalid input.
 //
+// Settings following a section header "[name]" set the Var whose Name
+// is the setting's identifier prefixed with the section name and '.';
+// e.g., "port" in section "[server]" sets the Var named "server.port".
+// A header with no name, "[]", ends the section.  Sections don't
+// carry over into included files, nor out of them.
+//
+// Before reading the file, every Var with a non-empty Default that
+// has not been set from command line is set to its Default, so that
+// the file can override it.  A Var may not be both Required and have
+// a Default.
+//
 // The parsing sequence implies that even when a number is desired,
 // the quoted string "\x32\u0033" is the same as unquoted 23.
 func Parse(r io.Reader, filename string, vars []Var) error {
-	p := &parser{file: filename, vars: vars}
-	if p.file == "" {
-		p.file = "stdin"
+	return defaultOptions.Parse(r, filename, vars)
+}
+
+// ParseString parses the configuration in data like Parse.
+func ParseString(data, filename string, vars []Var) error {
+	return Parse(strings.NewReader(data), filename, vars)
+}
+
+// ParseBytes parses the configuration in data like Parse.
+func ParseBytes(data []byte, filename string, vars []Var) error {
+	return Parse(bytes.NewReader(data), filename, vars)
+}
+
+// ParseSection parses the configuration in length bytes of r starting
+// at offset off like Parse, e.g., a part of a larger file.  Line numbers
+// in errors start at startLine, the number of the section's first line
+// in the larger file, so that they point into it.
+func ParseSection(r io.ReaderAt, off, length int64, filename string, startLine int, vars []Var) error {
+	return defaultOptions.ParseSection(r, off, length, filename, startLine, vars)
+}
+
+// ParseSection is like the package-level ParseSection, but modified by o.
+func (o *Options) ParseSection(r io.ReaderAt, off, length int64, filename string, startLine int, vars []Var) error {
+	p := newParser(io.NewSectionReader(r, off, length), filename, vars, o)
+	if startLine > 0 {
+		p.phys = startLine - 1
 	}
-	if t, ok := r.(*bufio.Reader); ok {
-		p.r = t
-	} else {
-		p.r = bufio.NewReader(r)
+	return p.run()
+}
+
+// resetFile clears the record of vars having been set from
+// configuration file.
+func resetFile(vars []Var) {
+	for i := range vars {
+		vars[i].set = false
+	}
+}
+
+// forgetFile prepares vars for parsing the configuration file again,
+// clearing the record of their having been set from it and zeroing
+// the Vars set from it that have no Default, so that they don't keep
+// values from settings since removed.
+func forgetFile(vars []Var) {
+	for i := range vars {
+		if v := &vars[i]; v.set && !v.flagSet && v.Default == "" {
+			zeroValue(v.Val)
+		}
+	}
+	resetFile(vars)
+}
+
+// Reload parses the configuration file from r again after a previous
+// Parse with the same vars.  Unlike Parse, it doesn't complain about
+// variables having been set by the previous pass.  A variable removed
+// from the file reverts to its Default, as Parse sets every Var to its
+// Default before reading the file.  One having no Default is reset to
+// its zero value: Resetters, like StringSliceValue or EnumValue, are
+// Reset, and the simple Values of this package, like StringValue or
+// Int64Value, are set to zero.  Values defined by the program must
+// implement Resetter to be reverted; others keep their previous values.
+// Vars set from command line are left alone, and Required ones are
+// still required.
+func Reload(r io.Reader, filename string, vars []Var) error {
+	forgetFile(vars)
+	return Parse(r, filename, vars)
+}
+
+// NamedReader is a configuration file for ParseAll.
+type NamedReader struct {
+	Reader io.Reader // file contents
+	Name   string    // filename for error messages, or "" for "stdin"
+}
+
+// ParseAll parses the configuration files from readers in order,
+// like Parse, with later files overriding settings from earlier ones,
+// e.g., a per-user file overriding a system-wide one.  Setting
+// a variable more than once in the same file is still an error.
+// Defaults are set before reading the first file, and Required Vars
+// are checked after reading the last one.  Values accumulating
+// settings, like StringSliceValue, accumulate them within a file,
+// but, being Resetters, are reset by the first setting in every file,
+// so a later file overrides them as well.
+// With no readers, ParseAll is like Parse of an empty file.
+func ParseAll(readers []NamedReader, vars []Var) error {
+	return defaultOptions.ParseAll(readers, vars)
+}
+
+// ParseAll is like the package-level ParseAll, but modified by o.
+func (o *Options) ParseAll(readers []NamedReader, vars []Var) error {
+	if len(readers) == 0 {
+		readers = []NamedReader{{strings.NewReader(""), ""}}
+	}
+	set := make([]bool, len(vars))
+	for i := range vars {
+		set[i] = vars[i].set
+	}
+	var p *parser
+	for i, r := range readers {
+		p = newParser(r.Reader, r.Name, vars, o)
+		if i == 0 {
+			if err := p.setDefaults(); err != nil {
+				return err
+			}
+		}
+		resetFile(vars)
+		err := p.parse()
+		if err != nil && err != ErrStopParsing {
+			return err
+		}
+		for j := range vars {
+			set[j] = set[j] || vars[j].set
+		}
+		if err == ErrStopParsing {
+			break
+		}
+	}
+	for i := range vars {
+		vars[i].set = set[i]
+	}
+	return p.checkRequired()
+}
+
+// getenv returns the value of the environment variable name,
+// as described under Options.Env.
+func (p *parser) getenv(name string) string {
+	if p.opt.Env != nil {
+		return p.opt.Env[name]
 	}
+	return os.Getenv(name)
+}
+
+// envNameRE matches the name in $NAME.
+var envNameRE = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*`)
+
+// expandEnv returns s with environment variables expanded
+// as described under Options.Expand.
+func (p *parser) expandEnv(s string) string {
+	var b strings.Builder
 	for {
-		p.line++
-		p.ident, p.value = "", ""
-		buf, ispref, err := p.r.ReadLine()
+		i := strings.IndexByte(s, '$')
+		if i == -1 {
+			return b.String() + s
+		}
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		var name string
+		switch {
+		case strings.HasPrefix(s, "$"):
+			b.WriteByte('$')
+			s = s[1:]
+			continue
+		case strings.HasPrefix(s, "{"):
+			if j := strings.IndexByte(s, '}'); j > 1 {
+				name, s = s[1:j], s[j+1:]
+			}
+		default:
+			name = envNameRE.FindString(s)
+			s = s[len(name):]
+		}
+		if name == "" {
+			b.WriteByte('$')
+		} else {
+			b.WriteString(p.getenv(name))
+		}
+	}
+}
+
+// directive executes the directive d.
+func (p *parser) directive(d *Directive) error {
+	switch d.Name {
+	case "include":
+		if len(d.Args) != 1 {
+			return p.newError(ErrSyntax)
+		}
+		return p.include(d.Args[0])
+	case "include-if":
+		if len(d.Args) != 2 {
+			return p.newError(ErrSyntax)
+		}
+		i := strings.Index(d.Args[0], "=")
+		if i < 1 {
+			return p.newError(ErrSyntax)
+		}
+		if p.open == nil {
+			return p.newError(ErrNoInclude)
+		}
+		if p.getenv(d.Args[0][:i]) != d.Args[0][i+1:] {
+			return nil
+		}
+		return p.include(d.Args[1])
+	}
+	return p.newError(ErrUnknownDir)
+}
+
+// parse reads the file and sets the variables.
+func (p *parser) parse() error {
+	for {
+		if err := p.ctx.Err(); err != nil {
+			return err
+		}
+		e, err := p.next()
 		if err == io.EOF {
-			break
+			return nil
 		} else if err != nil {
 			return err
-		} else if ispref {
-			return p.newError(errLineTooLong)
 		}
-		if err = p.parseLine(string(buf)); err != nil {
+		switch e := e.(type) {
+		case *Section:
+			p.sect = e.Name
+		case *Assignment:
+			if p.sect != "" {
+				p.ident = p.sect + "." + p.ident
+			}
+			value := e.Unquoted
+			if p.opt.Expand {
+				value = p.expandEnv(value)
+			}
+			if p.opt.TrimSpace {
+				value = strings.TrimSpace(value)
+			}
+			err = p.setValue(value, e.Append, e.Bare)
+		case *Directive:
+			err = p.directive(e)
+		}
+		if err != nil {
 			return err
 		}
 	}
+}
+
+// run sets the defaults, parses the file and checks that
+// required variables have been set.
+func (p *parser) run() error {
+	if err := p.setDefaults(); err != nil {
+		return err
+	}
+	if err := p.parse(); err != nil && err != ErrStopParsing {
+		return err
+	}
+	return p.checkRequired()
+}
+
+// checkRequired returns an error if a Required Var has not been set.
+func (p *parser) checkRequired() error {
 	for _, v := range p.vars {
 		if v.Required && !v.set {
-			return &ParseError{p.file, 0, v.Name, "", errReqNotSet}
+			return &ParseError{p.file, 0, 0, v.Name, "", ErrReqNotSet}
 		}
 	}
 	return nil
 }
+
+// Parse is like the package-level Parse, but modified by o.
+func (o *Options) Parse(r io.Reader, filename string, vars []Var) error {
+	return newParser(r, filename, vars, o).run()
+}
+
+// ParseContext is like Parse, but passes ctx to Values implementing
+// SetterContext.  If ctx is done, parsing stops, and ctx.Err() or
+// the error returned by SetContext, wrapped in ParseError, is
+// returned.
+func ParseContext(ctx context.Context, r io.Reader, filename string, vars []Var) error {
+	return defaultOptions.ParseContext(ctx, r, filename, vars)
+}
+
+// ParseContext is like the package-level ParseContext, but modified by o.
+func (o *Options) ParseContext(ctx context.Context, r io.Reader, filename string, vars []Var) error {
+	p := newParser(r, filename, vars, o)
+	p.ctx = ctx
+	return p.run()
+}
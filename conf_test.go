@@ -0,0 +1,1278 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var expandTests = []struct {
+	in, out string
+}{
+	{`$HOME/x`, `/home/u/x`},
+	{`${HOME}x`, `/home/ux`},
+	{`$$HOME`, `$HOME`},
+	{`$MISSING.`, `.`},
+	{`${MISSING}`, ``},
+	{`${A${B}}`, `}`},
+	{`cost $5`, `cost $5`},
+	{`$* $# $@ $! $? $- $0`, `$* $# $@ $! $? $- $0`},
+	{`${}`, `${}`},
+	{`${HOME`, `${HOME`},
+	{`trailing $`, `trailing $`},
+	{`${A B}`, `ab`},
+}
+
+func TestExpand(t *testing.T) {
+	o := &Options{Expand: true, Env: map[string]string{
+		"HOME": "/home/u",
+		"A B":  "ab",
+	}}
+	for _, test := range expandTests {
+		var s string
+		vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+		err := o.Parse(strings.NewReader("s = "+Quote(test.in)+"\n"),
+			"", vars)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if s != test.out {
+			t.Errorf("%q: got %q, want %q", test.in, s, test.out)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	var (
+		s, d, f string
+		n       int64
+		l       []string
+	)
+	vars := []Var{
+		{Name: "s", Val: (*StringValue)(&s)},
+		{Name: "d", Val: (*StringValue)(&d), Default: "def"},
+		{Name: "n", Val: (*Int64Value)(&n)},
+		{Name: "l", Val: (*StringSliceValue)(&l)},
+		{Name: "f", Flag: 'f', Val: (*StringValue)(&f)},
+	}
+	if _, err := defaultOptions.getOpt([]string{"-f", "flag"}, vars,
+		short); err != nil {
+		t.Fatal(err)
+	}
+	in := "s = a\nd = b\nn = 1\nl = x\nl = y\nf = file\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(strings.NewReader("l = z\n"), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if s != "" || d != "def" || n != 0 || f != "flag" {
+		t.Errorf("got s=%q d=%q n=%d f=%q, want \"\", \"def\", 0, \"flag\"",
+			s, d, n, f)
+	}
+	if want := []string{"z"}; !reflect.DeepEqual(l, want) {
+		t.Errorf("got l=%q, want %q", l, want)
+	}
+	if err := Reload(strings.NewReader(""), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if l != nil {
+		t.Errorf("got l=%q after removing it, want nil", l)
+	}
+}
+
+func TestReloadValues(t *testing.T) {
+	var (
+		e string
+		p int64
+	)
+	l := labels{}
+	c := &choice{choices: []string{"a", "b"}}
+	vars := []Var{
+		{Name: "e", Val: NewEnumValue(&e, "x", "y")},
+		{Name: "p", Val: NewIntRangeValue(&p, 1, 10)},
+		{Name: "l", Val: &l},
+		{Name: "mode", Val: c},
+	}
+	in := "e = y\np = 5\nl = k\nmode = a\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reload(strings.NewReader(""), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if e != "" || p != 0 {
+		t.Errorf("got e=%q p=%d after removing them, want \"\", 0", e, p)
+	}
+	// user Values that aren't Resetters are left alone
+	if l == nil || c.val != "a" {
+		t.Errorf("got l=%v mode=%q, want them kept", l, c.val)
+	}
+	in = "l = m\nmode = b\n"
+	if err := Reload(strings.NewReader(in), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if l["m"] != "m" || c.val != "b" {
+		t.Errorf("got l=%v mode=%q, want m set and \"b\"", l, c.val)
+	}
+}
+
+var continuedTests = []struct {
+	opt  Options
+	in   string
+	want string
+}{
+	{Options{}, "a = b\\\n  c\n", "bc"},
+	{Options{}, "a = b # c\\\n", "b"},
+	{Options{HashInValues: true}, "a = #ff\\\n00\n", "#ff00"},
+	{Options{HashInValues: true}, "a = b#c\\\nd\n", "b#cd"},
+	{Options{HashInValues: true}, "a = là#y\\\nz\n", "là#yz"},
+	{Options{HashInValues: true}, "a = #ff # c\\\n", "#ff"},
+	{Options{HashInValues: true}, "a += #ff\\\n00\n", "#ff00"},
+	{Options{SemicolonComments: true}, "a = ;x\\\ny\n", ";xy"},
+	{Options{SemicolonComments: true}, "a = x ;c\\\n", "x"},
+	{Options{SemicolonComments: true}, "a = x;y\\\nz\n", "x;yz"},
+	{Options{SemicolonComments: true}, "a = Å;y\\\nz\n", "Å;yz"},
+	{Options{}, "a = \"b\\\\\"\n", "b\\"},
+}
+
+func TestContinued(t *testing.T) {
+	for _, test := range continuedTests {
+		var l []string
+		vars := []Var{{Name: "a", Val: (*StringSliceValue)(&l)}}
+		err := test.opt.Parse(strings.NewReader(test.in), "", vars)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if len(l) != 1 || l[0] != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, l, test.want)
+		}
+	}
+}
+
+func TestParseStringBytes(t *testing.T) {
+	var s string
+	vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+	if err := ParseString("s = str\n", "", vars); err != nil || s != "str" {
+		t.Errorf("ParseString: got %q, %v", s, err)
+	}
+	Reset(vars)
+	if err := ParseBytes([]byte("s = bytes\n"), "", vars); err != nil ||
+		s != "bytes" {
+		t.Errorf("ParseBytes: got %q, %v", s, err)
+	}
+	Reset(vars)
+	var pe *ParseError
+	err := ParseString("x = 1\n", "mem.conf", vars)
+	if !errors.As(err, &pe) || pe.File != "mem.conf" {
+		t.Errorf("ParseString: got %v, want ParseError in mem.conf", err)
+	}
+	err = ParseBytes([]byte("x = 1\n"), "", vars)
+	if !errors.As(err, &pe) || pe.File != "stdin" {
+		t.Errorf("ParseBytes: got %v, want ParseError in stdin", err)
+	}
+}
+
+func TestAllowMultiple(t *testing.T) {
+	var got []string
+	add := FuncValue(func(s string) error {
+		got = append(got, s)
+		return nil
+	})
+	vars := []Var{{Name: "a", Val: add, AllowMultiple: true}}
+	if err := ParseString("a = 1\na = 2\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	vars = []Var{{Name: "a", Val: add}}
+	err := ParseString("a = 1\na = 2\n", "", vars)
+	if !errors.Is(err, ErrAlreadyDef) {
+		t.Errorf("got %v, want ErrAlreadyDef", err)
+	}
+}
+
+func TestSections(t *testing.T) {
+	var top, server, client, after string
+	vars := []Var{
+		{Name: "port", Val: (*StringValue)(&top)},
+		{Name: "server.port", Val: (*StringValue)(&server)},
+		{Name: "client.port", Val: (*StringValue)(&client)},
+		{Name: "after", Val: (*StringValue)(&after)},
+	}
+	const in = "port = 1\n[server]\nport = 2\n[client]\nport = 3\n[]\nafter = 4\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if top != "1" || server != "2" || client != "3" || after != "4" {
+		t.Errorf("got %q, %q, %q, %q, want 1, 2, 3, 4",
+			top, server, client, after)
+	}
+	Reset(vars)
+	var pe *ParseError
+	err := ParseString("[server]\nafter = 4\n", "", vars)
+	if !errors.As(err, &pe) || pe.Ident != "server.after" ||
+		!errors.Is(err, ErrUnknownVar) {
+		t.Errorf("got %v, want unknown server.after", err)
+	}
+}
+
+func TestContinuation(t *testing.T) {
+	var s string
+	vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+	if err := ParseString("s = one\\\ntwo\\\nthree\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if s != "onetwothree" {
+		t.Errorf("got %q, want %q", s, "onetwothree")
+	}
+	Reset(vars)
+	var pe *ParseError
+	err := ParseString("s = a\\\nb\\\nc\nx = 1\n", "", vars)
+	if !errors.As(err, &pe) || pe.Line != 4 || pe.Ident != "x" {
+		t.Errorf("got %v, want error for x at line 4", err)
+	}
+}
+
+var multilineTests = []struct {
+	in   string
+	want string
+}{
+	{"s = \"\"\"\nline 1\nline 2\"\"\"\n", "line 1\nline 2"},
+	{"s = \"\"\"line 1\n\tline 2\n\"\"\"\n", "line 1\n\tline 2\n"},
+	{"s = \"\"\"\"\"\"\n", ""},
+	{"s = \"\"\"a # b\"\"\" # comment\n", "a # b"},
+}
+
+func TestMultiline(t *testing.T) {
+	for _, test := range multilineTests {
+		var s string
+		vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+		if err := ParseString(test.in, "", vars); err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if s != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, s, test.want)
+		}
+	}
+	var s string
+	vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+	var pe *ParseError
+	err := ParseString("\ns = \"\"\"a\nb\nc\n", "", vars)
+	if !errors.As(err, &pe) || pe.Line != 2 || !errors.Is(err, ErrUnclosed) {
+		t.Errorf("got %v, want ErrUnclosed at line 2", err)
+	}
+}
+
+func TestErrorsIs(t *testing.T) {
+	var n int64
+	vars := []Var{{Flag: 'n', Name: "n", Val: (*Int64Value)(&n)}}
+	var pe *ParseError
+	err := ParseString("n = 99999999999999999999\n", "", vars)
+	if !errors.As(err, &pe) || pe.Ident != "n" ||
+		!errors.Is(err, strconv.ErrRange) {
+		t.Errorf("range: got %#v, want ParseError wrapping ErrRange", err)
+	}
+	Reset(vars)
+	err = ParseString("n 1\n", "", vars)
+	if !errors.As(err, &pe) || !errors.Is(err, ErrSyntax) {
+		t.Errorf("syntax: got %#v, want ParseError wrapping ErrSyntax", err)
+	}
+	Reset(vars)
+	var fe *FlagError
+	_, err = defaultOptions.getOpt([]string{"-n", "1e99"}, vars, short)
+	if !errors.As(err, &fe) || fe.Flag != 'n' ||
+		!errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("flag: got %#v, want FlagError wrapping ErrSyntax", err)
+	}
+	Reset(vars)
+	_, err = defaultOptions.getOpt([]string{"-n"}, vars, short)
+	if !errors.As(err, &fe) || !errors.Is(err, ErrNoArg) {
+		t.Errorf("flag: got %#v, want FlagError wrapping ErrNoArg", err)
+	}
+	Reset(vars)
+	_, err = defaultOptions.getOpt([]string{"-x"}, vars, short)
+	if !errors.As(err, &fe) || !errors.Is(err, ErrIllegalOption) {
+		t.Errorf("flag: got %#v, want FlagError wrapping ErrIllegalOption", err)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	var a, b, c string
+	var l []string
+	vars := []Var{
+		{Name: "a", Val: (*StringValue)(&a)},
+		{Name: "b", Val: (*StringValue)(&b), Default: "default"},
+		{Name: "c", Val: (*StringValue)(&c), Required: true},
+		{Name: "l", Val: (*StringSliceValue)(&l)},
+	}
+	readers := []NamedReader{
+		{strings.NewReader("a = 1\nb = 1\nl = 1\nl = 2\n"), "system"},
+		{strings.NewReader("a = 2\nc = 2\nl = 3\n"), "user"},
+	}
+	if err := ParseAll(readers, vars); err != nil {
+		t.Fatal(err)
+	}
+	if a != "2" || b != "1" || c != "2" ||
+		!reflect.DeepEqual(l, []string{"3"}) {
+		t.Errorf("got %q, %q, %q, %q, want 2, 1, 2, [3]", a, b, c, l)
+	}
+
+	Reset(vars)
+	var pe *ParseError
+	readers = []NamedReader{
+		{strings.NewReader("c = 1\n"), "system"},
+		{strings.NewReader("a = 1\na = 2\n"), "user"},
+	}
+	err := ParseAll(readers, vars)
+	if !errors.As(err, &pe) || pe.File != "user" || pe.Line != 2 ||
+		!errors.Is(err, ErrAlreadyDef) {
+		t.Errorf("got %v, want ErrAlreadyDef at user:2", err)
+	}
+
+	Reset(vars)
+	readers = []NamedReader{
+		{strings.NewReader("c = 1\n"), "system"},
+		{strings.NewReader("a = 1\n"), "user"},
+	}
+	if err := ParseAll(readers, vars); err != nil {
+		t.Errorf("required set in first file: %v", err)
+	}
+	Reset(vars)
+	readers = []NamedReader{{strings.NewReader("a = 1\n"), "system"}}
+	if err := ParseAll(readers, vars); !errors.Is(err, ErrReqNotSet) {
+		t.Errorf("got %v, want ErrReqNotSet", err)
+	}
+}
+
+func TestTextValue(t *testing.T) {
+	var (
+		tm time.Time
+		ip net.IP
+	)
+	vars := []Var{
+		{Name: "time", Val: TextValue(&tm)},
+		{Name: "ip", Val: TextValue(&ip)},
+	}
+	const in = "time = 2024-01-02T15:04:05Z\nip = \"::1\"\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if !tm.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)) ||
+		!ip.Equal(net.IPv6loopback) {
+		t.Errorf("got %v, %v", tm, ip)
+	}
+	for i, want := range []string{"2024-01-02T15:04:05Z", "::1"} {
+		if s, _ := ValueString(vars[i].Val); s != want {
+			t.Errorf("%s: String: got %q, want %q", vars[i].Name, s, want)
+		}
+	}
+	Reset(vars)
+	var pe *ParseError
+	err := ParseString("ip = 1.2.3\n", "", vars)
+	if !errors.As(err, &pe) || pe.Ident != "ip" {
+		t.Errorf("got %v, want ParseError for ip", err)
+	}
+}
+
+// setOnly is a Value with no String method.
+type setOnly struct{ s *string }
+
+func (v setOnly) Set(s string) error { *v.s = s; return nil }
+
+func TestFlagValue(t *testing.T) {
+	var d time.Duration
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.DurationVar(&d, "d", 0, "")
+	vars := []Var{{Name: "d", Val: FromFlagValue(fs.Lookup("d").Value)}}
+	if err := ParseString("d = 1m\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if d != time.Minute {
+		t.Errorf("FromFlagValue: got %v, want 1m", d)
+	}
+
+	var n int64
+	var s string
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(ToFlagValue((*Int64Value)(&n)), "n", "")
+	fs.Var(ToFlagValue(setOnly{&s}), "s", "")
+	if err := fs.Parse([]string{"-n", "42", "-s", "str"}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 42 || s != "str" {
+		t.Errorf("ToFlagValue: got %d, %q, want 42, \"str\"", n, s)
+	}
+	if got := fs.Lookup("n").Value.String(); got != "42" {
+		t.Errorf("ToFlagValue: String: got %q, want \"42\"", got)
+	}
+	if got := fs.Lookup("s").Value.String(); got != "" {
+		t.Errorf("ToFlagValue: String: got %q, want \"\"", got)
+	}
+}
+
+var hashInValuesTests = []struct {
+	in   string
+	want string
+}{
+	{"color = #ff0000 # real comment\n", "#ff0000"},
+	{"color = #ff0000#00\n", "#ff0000#00"},
+	{"url = http://host/a#frag\n", "http://host/a#frag"},
+	{"color = \"# quoted\" # comment\n", "# quoted"},
+	{"color = red\t# comment\n", "red"},
+}
+
+func TestHashInValues(t *testing.T) {
+	o := Options{HashInValues: true}
+	for _, test := range hashInValuesTests {
+		var s string
+		vars := []Var{
+			{Name: "color", Val: (*StringValue)(&s)},
+			{Name: "url", Val: (*StringValue)(&s)},
+		}
+		err := o.Parse(strings.NewReader(test.in), "", vars)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if s != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, s, test.want)
+		}
+	}
+	var s string
+	vars := []Var{{Name: "color", Val: (*StringValue)(&s)}}
+	err := ParseString("color = #ff0000 # comment\n", "", vars)
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("default mode: got %q, %v, want ErrSyntax", s, err)
+	}
+}
+
+// rawValue records the arguments of SetRaw.
+type rawValue struct {
+	raw, unquoted string
+}
+
+func (v *rawValue) Set(s string) error { v.raw, v.unquoted = "", s; return nil }
+
+func (v *rawValue) SetRaw(raw, unquoted string) error {
+	v.raw, v.unquoted = raw, unquoted
+	return nil
+}
+
+func TestRawSetter(t *testing.T) {
+	for _, test := range []struct{ in, raw, unquoted string }{
+		{`s = plain`, "plain", "plain"},
+		{`s = "a\tb" # comment`, `"a\tb"`, "a\tb"},
+	} {
+		var v rawValue
+		vars := []Var{{Name: "s", Val: &v}}
+		if err := ParseString(test.in, "", vars); err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if v.raw != test.raw || v.unquoted != test.unquoted {
+			t.Errorf("%q: got %q, %q, want %q, %q", test.in,
+				v.raw, v.unquoted, test.raw, test.unquoted)
+		}
+	}
+	var v rawValue
+	vars := []Var{{Name: "s", Val: &v, Default: "def"}}
+	if err := ParseString("", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if v.raw != "" || v.unquoted != "def" {
+		t.Errorf("Default: got %q, %q, want Set(\"def\")", v.raw, v.unquoted)
+	}
+}
+
+// slowValue is a SetterContext whose SetContext waits for ctx.
+type slowValue struct{ s string }
+
+func (v *slowValue) Set(s string) error { v.s = s; return nil }
+
+func (v *slowValue) SetContext(ctx context.Context, s string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(10 * time.Second):
+	}
+	v.s = s
+	return nil
+}
+
+func TestParseContext(t *testing.T) {
+	var (
+		slow slowValue
+		s    string
+	)
+	vars := []Var{
+		{Name: "slow", Val: &slow},
+		{Name: "s", Val: (*StringValue)(&s)},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+	err := ParseContext(ctx, strings.NewReader("s = a\nslow = b\n"), "", vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Ident != "slow" ||
+		!errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want DeadlineExceeded for slow", err)
+	}
+	if s != "a" || slow.s != "" {
+		t.Errorf("got %q, %q, want \"a\", \"\"", s, slow.s)
+	}
+}
+
+var columnTests = []struct {
+	in   string
+	line int
+	col  int
+	msg  string
+}{
+	{"a = 1\nn = 12x\n", 2, 5, "stdin:2:5: n: invalid syntax\n"},
+	{"a = 1\n  n  =   oops # comment\n", 2, 10, "stdin:2:10: n: invalid syntax\n"},
+	{"a = 1\nxyz = 2\n", 2, 1, "stdin:2:1: xyz: unknown variable\n"},
+	{"", 0, 0, "stdin: a: required but not set\n"},
+}
+
+func TestColumn(t *testing.T) {
+	for _, test := range columnTests {
+		var a string
+		var n int64
+		vars := []Var{
+			{Name: "a", Val: (*StringValue)(&a), Required: true},
+			{Name: "n", Val: (*Int64Value)(&n)},
+		}
+		err := ParseString(test.in, "", vars)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%q: got %v, want ParseError", test.in, err)
+		} else if pe.Line != test.line || pe.Column != test.col ||
+			pe.Error() != test.msg {
+			t.Errorf("%q: got %d:%d %q, want %d:%d %q", test.in,
+				pe.Line, pe.Column, pe, test.line, test.col,
+				test.msg)
+		}
+	}
+}
+
+var crTests = []struct {
+	in string
+	ok bool
+}{
+	{"a = 1\nb = 2\n", true},
+	{"a = 1\r\nb = 2\r\n", true},
+	{"a = 1\r\nb = 2", true},
+	{"a = 1\rb = 2\n", false},
+	{"a = \"1\r\"\n", false},
+	{"a = 1\r\r\n", false},
+}
+
+func TestCR(t *testing.T) {
+	for _, test := range crTests {
+		var a, b string
+		vars := []Var{
+			{Name: "a", Val: (*StringValue)(&a)},
+			{Name: "b", Val: (*StringValue)(&b)},
+		}
+		err := ParseString(test.in, "", vars)
+		var pe *ParseError
+		switch {
+		case !test.ok:
+			if !errors.As(err, &pe) || !errors.Is(err, errStrayCR) ||
+				!errors.Is(err, ErrSyntax) || pe.Line != 1 {
+				t.Errorf("%q: got %v, want stray CR at line 1",
+					test.in, err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", test.in, err)
+		case a != "1" || b != "2":
+			t.Errorf("%q: got %q, %q, want \"1\", \"2\"", test.in, a, b)
+		}
+	}
+}
+
+func TestTrimSpace(t *testing.T) {
+	const in = "q = \"  x  \"\np = x\n"
+	for _, test := range []struct {
+		opt  Options
+		q, p string
+	}{
+		{Options{}, "  x  ", "x"},
+		{Options{TrimSpace: true}, "x", "x"},
+	} {
+		var q, p string
+		vars := []Var{
+			{Name: "q", Val: (*StringValue)(&q)},
+			{Name: "p", Val: (*StringValue)(&p)},
+		}
+		if err := test.opt.Parse(strings.NewReader(in), "", vars); err != nil {
+			t.Errorf("TrimSpace %v: %v", test.opt.TrimSpace, err)
+		} else if q != test.q || p != test.p {
+			t.Errorf("TrimSpace %v: got %q, %q, want %q, %q",
+				test.opt.TrimSpace, q, p, test.q, test.p)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	var (
+		path []string
+		s    string
+	)
+	vars := []Var{
+		{Name: "path", Val: (*StringSliceValue)(&path)},
+		{Name: "s", Val: (*StringValue)(&s)},
+	}
+	const in = "path = /usr/bin\npath += /usr/local/bin\npath+=/opt/bin\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/usr/bin", "/usr/local/bin", "/opt/bin"}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("got %q, want %q", path, want)
+	}
+	Reset(vars)
+	if err := ParseString("path += /bin\n", "", vars); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(path, []string{"/bin"}) {
+		t.Errorf("got %q, want [/bin]", path)
+	}
+	for _, in := range []string{"s += x\n", "s = x\ns += y\n"} {
+		Reset(vars)
+		err := ParseString(in, "", vars)
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Ident != "s" ||
+			!errors.Is(err, ErrNoAppend) {
+			t.Errorf("%q: got %v, want ErrNoAppend", in, err)
+		}
+	}
+}
+
+func TestStopParsing(t *testing.T) {
+	var mode, a, req string
+	stop := FuncValue(func(s string) error {
+		mode = s
+		if s == "legacy" {
+			return ErrStopParsing
+		}
+		return nil
+	})
+	vars := []Var{
+		{Name: "req", Val: (*StringValue)(&req), Required: true},
+		{Name: "mode", Val: stop},
+		{Name: "a", Val: (*StringValue)(&a)},
+	}
+	const in = "req = r\nmode = legacy\na = 1\nunknown = 2\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "legacy" || a != "" || req != "r" {
+		t.Errorf("got %q, %q, %q, want legacy, \"\", r", mode, a, req)
+	}
+	if !WasSet(&vars[1]) {
+		t.Error("mode not set")
+	}
+	Reset(vars)
+	err := ParseString("mode = legacy\nreq = r\n", "", vars)
+	if !errors.Is(err, ErrReqNotSet) {
+		t.Errorf("got %v, want ErrReqNotSet", err)
+	}
+}
+
+var maxLineTests = []struct {
+	max, n int
+	crlf   bool
+	ok     bool
+}{
+	{0, defaultMaxLine, false, true},
+	{0, defaultMaxLine, true, true},
+	{0, defaultMaxLine + 1, false, false},
+	{100, 100, true, true},
+	{100, 101, false, false},
+	{8192, 8192, false, true},
+}
+
+func TestMaxLine(t *testing.T) {
+	const prefix = "s = "
+	for _, test := range maxLineTests {
+		line := prefix + strings.Repeat("x", test.n-len(prefix))
+		if test.crlf {
+			line += "\r"
+		}
+		var s string
+		vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+		o := Options{MaxLine: test.max}
+		// a plain io.Reader, so that Parse does its own buffering
+		r := struct{ io.Reader }{strings.NewReader(line + "\n")}
+		err := o.Parse(r, "", vars)
+		switch {
+		case !test.ok:
+			if !errors.Is(err, ErrLineTooLong) {
+				t.Errorf("%d/%d: got %v, want ErrLineTooLong",
+					test.n, test.max, err)
+			}
+		case err != nil:
+			t.Errorf("%d/%d: %v", test.n, test.max, err)
+		case len(s) != test.n-len(prefix):
+			t.Errorf("%d/%d: got %d bytes", test.n, test.max, len(s))
+		}
+	}
+}
+
+func TestValueString(t *testing.T) {
+	n := Int64Value(42)
+	if s, ok := ValueString(&n); !ok || s != "42" {
+		t.Errorf("Int64Value: got %q, %v, want \"42\", true", s, ok)
+	}
+	var str string
+	if s, ok := ValueString(setOnly{&str}); ok || s != "" {
+		t.Errorf("setOnly: got %q, %v, want \"\", false", s, ok)
+	}
+}
+
+func TestVarCheck(t *testing.T) {
+	var port int64
+	checked := ""
+	vars := []Var{{
+		Flag: 'p', Name: "port", Val: (*Int64Value)(&port),
+		Check: func(s string) error {
+			checked = s
+			if port < 1 || port > 65535 {
+				return strconv.ErrRange
+			}
+			return nil
+		},
+	}}
+	if err := ParseString("port = 8080\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 || checked != "8080" {
+		t.Errorf("got %d, checked %q", port, checked)
+	}
+	Reset(vars)
+	var pe *ParseError
+	err := ParseString("port = 65536\n", "", vars)
+	if !errors.As(err, &pe) || pe.Ident != "port" ||
+		!errors.Is(err, strconv.ErrRange) {
+		t.Errorf("Parse: got %v, want ErrRange for port", err)
+	}
+	Reset(vars)
+	checked = ""
+	var fe *FlagError
+	_, err = defaultOptions.getOpt([]string{"-p", "0"}, vars, short)
+	if !errors.As(err, &fe) || fe.Flag != 'p' ||
+		!errors.Is(err, strconv.ErrRange) {
+		t.Errorf("GetOpt: got %v, want ErrRange for -p", err)
+	}
+	Reset(vars)
+	checked = ""
+	_, err = defaultOptions.getOpt([]string{"-p", "x"}, vars, short)
+	if err == nil || checked != "" {
+		t.Errorf("Check called after failed Set: %v, %q", err, checked)
+	}
+}
+
+func TestParseSection(t *testing.T) {
+	const (
+		head = "# bundle\nother = 1\n"
+		sect = "a = 1\n\nb = 2\n"
+		tail = "other = 2\n"
+	)
+	data := strings.NewReader(head + sect + tail)
+	var a, b string
+	vars := []Var{
+		{Name: "a", Val: (*StringValue)(&a)},
+		{Name: "b", Val: (*StringValue)(&b)},
+	}
+	err := ParseSection(data, int64(len(head)), int64(len(sect)),
+		"bundle", 3, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != "1" || b != "2" {
+		t.Errorf("got %q, %q, want \"1\", \"2\"", a, b)
+	}
+	Reset(vars)
+	var pe *ParseError
+	err = ParseSection(data, int64(len(head)), int64(len(sect+tail)),
+		"bundle", 3, vars)
+	if !errors.As(err, &pe) || pe.File != "bundle" || pe.Line != 6 ||
+		pe.Ident != "other" {
+		t.Errorf("got %v, want unknown other at bundle:6", err)
+	}
+}
+
+var decimalTests = []struct {
+	in      string
+	base0   int64
+	decimal int64
+	ok      bool // for DecimalInt64Value
+}{
+	{"010", 8, 10, true},
+	{"10", 10, 10, true},
+	{"-007", -7, -7, true},
+	{"0x10", 16, 0, false},
+	{"0b1", 1, 0, false},
+	{"1_000", 1000, 0, false},
+}
+
+func TestDecimalInt64Value(t *testing.T) {
+	for _, test := range decimalTests {
+		var n Int64Value
+		if err := n.Set(test.in); err != nil || int64(n) != test.base0 {
+			t.Errorf("Int64Value %q: got %d, %v, want %d",
+				test.in, n, err, test.base0)
+		}
+		var d DecimalInt64Value
+		err := d.Set(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("DecimalInt64Value %q: got error %v", test.in, err)
+		} else if int64(d) != test.decimal {
+			t.Errorf("DecimalInt64Value %q: got %d, want %d",
+				test.in, d, test.decimal)
+		}
+	}
+}
+
+func TestReparseSlice(t *testing.T) {
+	var (
+		l []string
+		n CountValue
+		m map[string]string
+	)
+	vars := []Var{
+		{Name: "l", Val: (*StringSliceValue)(&l)},
+		{Name: "n", Flag: 'n', Kind: NoArg, Val: &n},
+		{Name: "m", Val: NewMapValue(&m)},
+	}
+	const in = "l = a\nl = b\nn = true\nn = true\nm = \"a=1\"\nm = \"b=2\"\n"
+	for i := 0; i < 3; i++ {
+		Reset(vars)
+		if err := ParseString(in, "", vars); err != nil {
+			t.Fatal(err)
+		}
+		if len(l) != 2 || n != 2 || len(m) != 2 {
+			t.Errorf("pass %d: got %q, %d, %q", i+1, l, n, m)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := Reload(strings.NewReader(in), "", vars); err != nil {
+			t.Fatal(err)
+		}
+		if len(l) != 2 || n != 2 || len(m) != 2 {
+			t.Errorf("reload %d: got %q, %d, %q", i+1, l, n, m)
+		}
+	}
+	Reset(vars)
+	_, err := defaultOptions.getOpt([]string{"-n", "-n", "-n"}, vars, short)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("GetOpt: got %d, want 3", n)
+	}
+}
+
+var semicolonTests = []struct {
+	in   string
+	want string
+	ok   bool // under SemicolonComments
+}{
+	{"; comment\na = x\n", "x", true},
+	{"  ; indented comment\na = x\n", "x", true},
+	{"a = x ; comment\n", "x", true},
+	{"a = b;c ; comment\n", "b;c", true},
+	{"a = x;\n", "x;", true},
+	{"a = ;x\n", ";x", true},
+	{"a = \"x ; y\" ; z\n", "x ; y", true},
+	{"a ; x\n", "", false},
+}
+
+func TestSemicolonComments(t *testing.T) {
+	o := Options{SemicolonComments: true}
+	for _, test := range semicolonTests {
+		var a string
+		vars := []Var{{Name: "a", Val: (*StringValue)(&a)}}
+		err := o.Parse(strings.NewReader(test.in), "", vars)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v", test.in, err)
+		} else if a != test.want {
+			t.Errorf("%q: got %q, want %q", test.in, a, test.want)
+		}
+	}
+	var a string
+	vars := []Var{{Name: "a", Val: (*StringValue)(&a)}}
+	if err := ParseString("; comment\n", "", vars); err == nil {
+		t.Error("';' comment accepted by default")
+	}
+	Reset(vars)
+	if err := ParseString("a = b;c\n", "", vars); err != nil || a != "b;c" {
+		t.Errorf("default: got %q, %v, want \"b;c\"", a, err)
+	}
+}
+
+func TestEqualsInValues(t *testing.T) {
+	const in = "q = a=b&c=d\n"
+	var q string
+	vars := []Var{{Name: "q", Val: (*StringValue)(&q)}}
+	o := Options{EqualsInValues: true}
+	if err := o.Parse(strings.NewReader(in), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if q != "a=b&c=d" {
+		t.Errorf("got %q, want %q", q, "a=b&c=d")
+	}
+	Reset(vars)
+	err := o.Parse(strings.NewReader("q = =x\n"), "", vars)
+	if err != nil || q != "=x" {
+		t.Errorf("leading '=': got %q, %v, want \"=x\"", q, err)
+	}
+	Reset(vars)
+	q = ""
+	if err = ParseString(in, "", vars); !errors.Is(err, ErrSyntax) {
+		t.Errorf("default: got %q, %v, want ErrSyntax", q, err)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	var (
+		addr  string
+		warns []string
+	)
+	vars := []Var{{Name: "listen", Val: (*StringValue)(&addr),
+		Deprecated: []string{"bind", "address"}}}
+	o := Options{Warn: func(msg string) { warns = append(warns, msg) }}
+	err := o.Parse(strings.NewReader("\nbind = :80\n"), "old.conf", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"old.conf:2: option 'bind' is deprecated, use 'listen'"}
+	if addr != ":80" || !reflect.DeepEqual(warns, want) {
+		t.Errorf("got %q, %q, want \":80\", %q", addr, warns, want)
+	}
+
+	Reset(vars)
+	warns = nil
+	err = o.Parse(strings.NewReader("listen = :81\n"), "new.conf", vars)
+	if err != nil || addr != ":81" || warns != nil {
+		t.Errorf("current name: got %q, %q, %v", addr, warns, err)
+	}
+	Reset(vars)
+	err = o.Parse(strings.NewReader("bind = :80\nlisten = :81\n"), "", vars)
+	if !errors.Is(err, ErrAlreadyDef) {
+		t.Errorf("both names: got %v, want ErrAlreadyDef", err)
+	}
+	Reset(vars)
+	if err := ParseString("address = :82\n", "", vars); err != nil ||
+		addr != ":82" {
+		t.Errorf("no Warn: got %q, %v", addr, err)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	var out string
+	vars := []Var{{Flag: 'o', Name: "output", Val: (*StringValue)(&out),
+		Aliases: []string{"out"}, AliasFlags: []rune{'O'}}}
+	if err := ParseString("out = file\n", "", vars); err != nil ||
+		out != "file" {
+		t.Errorf("Parse alias: got %q, %v", out, err)
+	}
+	Reset(vars)
+	err := ParseString("output = a\nout = b\n", "", vars)
+	if !errors.Is(err, ErrAlreadyDef) {
+		t.Errorf("Parse both: got %v, want ErrAlreadyDef", err)
+	}
+	for _, args := range [][]string{
+		{"-O", "x"}, {"-Ox"}, {"--out", "x"}, {"--out=x"}, {"--output=x"},
+	} {
+		Reset(vars)
+		out = ""
+		_, err := defaultOptions.getOpt(args, vars, gnuLong)
+		if err != nil || out != "x" {
+			t.Errorf("%q: got %q, %v", args, out, err)
+		}
+	}
+	Reset(vars)
+	_, err = defaultOptions.getOpt([]string{"-o", "x", "-O", "y"}, vars,
+		gnuLong)
+	if !errors.Is(err, ErrAlreadySet) {
+		t.Errorf("GetOpt both: got %v, want ErrAlreadySet", err)
+	}
+}
+
+var trailingTests = []struct {
+	in, value string
+	col       int
+}{
+	{"port = 80 81\n", "81", 11},
+	{"port = 80 81 # comment\n", "81 # comment", 11},
+	{"port = \"80\"81\n", "81", 12},
+	{"port = 80 \"x\"\n", `"x"`, 11},
+}
+
+func TestTrailing(t *testing.T) {
+	for _, test := range trailingTests {
+		var port string
+		vars := []Var{{Name: "port", Val: (*StringValue)(&port)}}
+		err := ParseString(test.in, "", vars)
+		var pe *ParseError
+		if !errors.As(err, &pe) || !errors.Is(err, ErrTrailing) ||
+			!errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: got %v, want ErrTrailing", test.in, err)
+		} else if pe.Value != test.value || pe.Column != test.col {
+			t.Errorf("%q: got %q at %d, want %q at %d", test.in,
+				pe.Value, pe.Column, test.value, test.col)
+		}
+	}
+	var port string
+	vars := []Var{{Name: "port", Val: (*StringValue)(&port)}}
+	const want = "stdin:1:11: port: syntax error: " +
+		"unexpected text after value: 81\n"
+	if err := ParseString("port = 80 81", "", vars); err == nil ||
+		err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+func TestUnicodeIdents(t *testing.T) {
+	var a, b string
+	vars := []Var{
+		{Name: "café", Val: (*StringValue)(&a)},
+		{Name: "s.naïve", Val: (*StringValue)(&b)},
+	}
+	const in = "café = x\n[s]\nnaïve = y\n"
+	o := Options{UnicodeIdents: true}
+	if err := o.Parse(strings.NewReader(in), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if a != "x" || b != "y" {
+		t.Errorf("got %q, %q, want \"x\", \"y\"", a, b)
+	}
+	Reset(vars)
+	err := o.Parse(strings.NewReader("éa = x\n"), "", vars)
+	if !errors.Is(err, ErrUnknownVar) {
+		t.Errorf("leading non-ASCII letter: got %v, want ErrUnknownVar", err)
+	}
+	Reset(vars)
+	err = o.Parse(strings.NewReader("1a = x\n"), "", vars)
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("leading digit: got %v, want ErrSyntax", err)
+	}
+	Reset(vars)
+	if err = ParseString(in, "", vars); !errors.Is(err, ErrSyntax) {
+		t.Errorf("default: got %v, want ErrSyntax", err)
+	}
+}
+
+func TestBareFlags(t *testing.T) {
+	var (
+		verbose bool
+		level   string
+	)
+	vars := []Var{
+		{Name: "verbose", Kind: NoArg, Val: (*BoolValue)(&verbose)},
+		{Name: "level", Val: (*StringValue)(&level)},
+	}
+	o := Options{BareFlags: true}
+	const in = "verbose # comment\nlevel = 2\n"
+	err := o.Parse(strings.NewReader(in), "", vars)
+	if err != nil || !verbose || level != "2" {
+		t.Errorf("got %v, %q, %v", verbose, level, err)
+	}
+	Reset(vars)
+	err = o.Parse(strings.NewReader("level\n"), "", vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Ident != "level" ||
+		!errors.Is(err, ErrNoValue) {
+		t.Errorf("bare level: got %v, want ErrNoValue", err)
+	}
+	Reset(vars)
+	verbose = false
+	if err = ParseString("verbose\n", "", vars); err == nil || verbose {
+		t.Errorf("default: got %v, %v, want error", verbose, err)
+	}
+}
+
+var uint64Tests = []struct {
+	in  string
+	out uint64
+	err error
+}{
+	{"0", 0, nil},
+	{"18446744073709551615", 1<<64 - 1, nil},
+	{"0xffffffffffffffff", 1<<64 - 1, nil},
+	{"-1", 0, errNegative},
+	{"-0", 0, errNegative},
+	{"18446744073709551616", 0, strconv.ErrRange},
+	{"0x10000000000000000", 0, strconv.ErrRange},
+	{"x", 0, strconv.ErrSyntax},
+}
+
+func TestUint64Value(t *testing.T) {
+	for _, test := range uint64Tests {
+		var v Uint64Value
+		err := v.Set(test.in)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%q: got error %v, want %v", test.in, err, test.err)
+		} else if uint64(v) != test.out {
+			t.Errorf("%q: got %d, want %d", test.in, v, test.out)
+		}
+	}
+	var u uint64
+	vars := []Var{{Name: "u", Val: (*Uint64Value)(&u)}}
+	err := ParseString("u = -1\n", "", vars)
+	if want := "stdin:1:5: u: negative value not allowed\n"; err == nil ||
+		err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+const maxInt = int(^uint(0) >> 1)
+
+var intTests = []struct {
+	in  string
+	out int
+	err error
+}{
+	{"0", 0, nil},
+	{"-42", -42, nil},
+	{"0x10", 16, nil},
+	{strconv.Itoa(maxInt), maxInt, nil},
+	{"1" + strconv.Itoa(maxInt), 0, strconv.ErrRange},
+	{"x", 0, strconv.ErrSyntax},
+}
+
+func TestIntValue(t *testing.T) {
+	for _, test := range intTests {
+		var v IntValue
+		err := v.Set(test.in)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%q: got error %v, want %v", test.in, err, test.err)
+		} else if int(v) != test.out {
+			t.Errorf("%q: got %d, want %d", test.in, v, test.out)
+		}
+	}
+}
+
+func TestVarHelpers(t *testing.T) {
+	var (
+		verbose bool
+		name    string
+		n       int64
+		got     []string
+	)
+	vars := []Var{
+		BoolVar('v', "verbose", &verbose, NoArg),
+		StringVar('n', "name", &name, HasArg),
+		Int64Var(0, "count", &n, HasArg),
+		Func('t', "tag", func(s string) error {
+			got = append(got, s)
+			return nil
+		}, HasArg),
+	}
+	vars[3].AllowMultiple = true
+	if err := Validate(vars); err != nil {
+		t.Fatal(err)
+	}
+	if err := ParseString("count = 3\ntag = a\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	argv := []string{"-v", "--name", "x", "-tb", "rest"}
+	rest, err := defaultOptions.getOpt(argv, vars, gnuLong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verbose || name != "x" || n != 3 ||
+		!reflect.DeepEqual(got, []string{"a", "b"}) ||
+		!reflect.DeepEqual(rest, []string{"rest"}) {
+		t.Errorf("got %v, %q, %d, %q, %q", verbose, name, n, got, rest)
+	}
+}
+
+type hookCall struct {
+	ident, value string
+	line         int
+}
+
+func TestUnknown(t *testing.T) {
+	var x, y string
+	var calls []hookCall
+	errBad := errors.New("bad setting")
+	o := Options{Unknown: func(ident, value string) error {
+		calls = append(calls, hookCall{ident, value, 0})
+		if ident == "bad" {
+			return errBad
+		}
+		return nil
+	}}
+	vars := []Var{
+		{Name: "sec.x", Val: (*StringValue)(&x)},
+		{Name: "y", Val: (*StringValue)(&y)},
+	}
+	in := "[sec]\nfoo = \"a b\"\nx = 1\n"
+	if err := o.Parse(strings.NewReader(in), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	want := []hookCall{{"sec.foo", "a b", 0}}
+	if x != "1" || !reflect.DeepEqual(calls, want) {
+		t.Errorf("got x=%q, calls %v, want \"1\", %v", x, calls, want)
+	}
+	Reset(vars)
+	err := o.Parse(strings.NewReader("\nbad = 1\ny = 2\n"), "", vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, errBad) || pe.Line != 2 {
+		t.Errorf("got %v, want ParseError at line 2 wrapping errBad", err)
+	}
+	if y != "" {
+		t.Errorf("got y=%q, want parsing stopped", y)
+	}
+}
+
+func TestOnSet(t *testing.T) {
+	var a, b, c, f string
+	var calls []hookCall
+	errBad := errors.New("bad setting")
+	o := Options{OnSet: func(ident, raw string, line int) error {
+		calls = append(calls, hookCall{ident, raw, line})
+		if raw == "bad" {
+			return errBad
+		}
+		return nil
+	}}
+	vars := []Var{
+		{Name: "a", Val: (*StringValue)(&a)},
+		{Name: "b", Val: (*StringValue)(&b)},
+		{Name: "c", Val: (*StringValue)(&c)},
+		{Flag: 'f', Name: "f", Val: (*StringValue)(&f)},
+	}
+	if _, err := o.getOpt([]string{"-f", "flag"}, vars, short); err != nil {
+		t.Fatal(err)
+	}
+	in := "b = 1\na = \"x y\"\nf = file\n\nc = plain\n"
+	if err := o.Parse(strings.NewReader(in), "", vars); err != nil {
+		t.Fatal(err)
+	}
+	want := []hookCall{{"b", "1", 1}, {"a", `"x y"`, 2}, {"c", "plain", 5}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got %v, want %v", calls, want)
+	}
+	Reset(vars)
+	c = ""
+	err := o.Parse(strings.NewReader("a = 1\nb = bad\nc = 3\n"), "", vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, errBad) || pe.Line != 2 {
+		t.Errorf("got %v, want ParseError at line 2 wrapping errBad", err)
+	}
+	if c != "" {
+		t.Errorf("got c=%q, want parsing stopped", c)
+	}
+}
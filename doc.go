@@ -14,20 +14,32 @@ and GetOptLongOnly.
 
 Configuration file syntax (see Parse() for semantics):
 
-The file is composed of lines of UTF-8 text, each no longer than 4KB.
-Comments start with '#' and continue to end of line.
+The file is composed of lines of UTF-8 text, each no longer than 4KB
+(by default; see Options.MaxLine).  Lines end in LF or CRLF; carriage
+returns anywhere else, even in comments, are syntax errors.
+Comments start with '#' and continue to end of line.  With
+Options.SemicolonComments, ';' at the start of a line or after
+whitespace starts a comment as well.
 Whitespace (Unicode character class Z) between tokens is ignored.
 Configuration settings look like this:
 
 	ident = value
 
+With Options.BareFlags, an identifier alone sets a NoArg Var
+to "true", like a flag on the command line.
+
 Identifiers start with an ASCII letter, dash ('-') or underscore ('_'),
 and continue with zero or more ASCII letters, ASCII digits, dashes or
 underscores.  That is, they match /[-_a-zA-Z][-_a-zA-Z0-9]/.
+Options.UnicodeIdents allows Unicode letters as well.
 
 Values may be plain or quoted.  Plain values may have any character in
 them besides space (Unicode character class Z), control characters
-(Unicode character class C), or any of '"', '#', `'`, '=', `\`.
+(Unicode character class C), or any of '"', '#', `'`, '=', `\`
+(Options.HashInValues and Options.EqualsInValues allow '#' and '='
+respectively).  Anything else, including "--", is a valid plain value.
+QuoteNeeded reports whether a string must be quoted, and Quote
+quotes it if so.
 
 Quoted values are enclosed in double quotes (like "this") and obey Go
 quoted string rules.  They may not include Unicode control characters.
@@ -38,6 +50,39 @@ accepted.  Quoted values, unlike plain ones, can be empty ("").
 The rule about control characters means that tabs inside quoted strings
 must be replaced with "\t" (or "\U00000009" or whatever).
 
+Multi-line values are enclosed in triple double quotes and may span
+several lines.  Everything between the delimiters, including line
+breaks and control characters, is taken literally, without processing
+backslash escapes, except that a line break immediately following the
+opening delimiter is removed.  Multi-line values can't contain three
+double quotes in a row.  For example:
+
+	banner = """
+	Welcome to the machine.
+	"""
+
+A line ending with a backslash ('\') outside of quoted values and
+comments is continued on the next line: the backslash, the line break
+and any whitespace at the beginning of the next line are removed.
+The length limit applies to each line separately.  For example:
+
+	path = /usr/local/bin:/usr/bin:\
+	       /bin
+
+Settings of variables accumulating values, like lists, can use "+="
+instead of "=" to make appending explicit:
+
+	path  = /usr/bin
+	path += /usr/local/bin
+
+Settings can be grouped into sections, each starting with a header
+line containing an identifier in square brackets, like "[server]".
+An empty header, "[]", returns to settings outside of any section.
+
+Lines starting with '@' are directives, consisting of the directive
+name and zero or more whitespace separated values.  The only directives
+are @include and @include-if, understood by ParseFile (see there).
+
 Example:
 
 	ipv6-addr = [::1]:23         # Look ma, no quotes!
@@ -48,10 +93,15 @@ ABNF:
 
 	; The language's charset is Unicode, encoding is UTF-8.
 
+	; Continuation lines are joined before parsing.
+
 	file         = *line
-	line         = [assignment] [comment] nl
-	assignment   = ows ident equals value
+	line         = [assignment / section / directive] [comment] nl
+	assignment   = ows ident equals assign-value
+	section      = ows "[" ows [ident] ows "]"
+	directive    = ows "@" ident *(1*WSP value)
 	value        = plain-value / quoted-value
+	assign-value = value / multi-value
 
 	; The token <opt-space> can appear anywhere and is ignored.
 
@@ -59,9 +109,10 @@ ABNF:
 
 	comment      = ows "#" *ctext
 	ident        = ident-alpha *ident-alnum
-	equals       = ows "=" ows
+	equals       = ows ("=" / "+=") ows
 	plain-value  = 1*ptext
 	quoted-value = DQUOTE *(qtext / quoted-pair) DQUOTE
+	multi-value  = 3DQUOTE *(mtext / nl) 3DQUOTE
 	ows          = *WSP
 	nl           = [CR] LF
 
@@ -77,10 +128,13 @@ ABNF:
 	unicode-val  = %x75 4HEXDIG		; u[0-9A-Fa-f]{4}
 		     / %x55 8HEXDIG		; U[0-9A-Fa-f]{8}
 
-	ctext        = %x00-09 / %x0B-10FFFF	; any CHAR excluding LF
+	ctext        = %x00-09 / %x0B-0C / %x0E-10FFFF
+						; any CHAR excluding CR, LF
 	ptext        = <any CHAR excluding WSP, CTL,
 			DQUOTE, "#", "'", "=", BACKSLASH>
 	qtext        = <any CHAR excluding CTL, DQUOTE, BACKSLASH>
+	mtext        = <any sequence of CHAR excluding CR, LF
+			not containing 3DQUOTE>
 	ascii-alpha  = %x41-5A / %x61-7A	; [A-Za-z]
 	octal-digit  = %x30-37			; [0-7]
 	HEXDIG       = DIGIT / %x41-56 / %x61-66; [0-9A-Fa-f]
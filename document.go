@@ -0,0 +1,165 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Element is a line of a configuration file as returned by
+// ReadDocument.  It is one of *Blank, *Comment, *Assignment,
+// *Directive or *Section.
+type Element interface {
+	element()
+}
+
+// Blank represents an empty or whitespace-only line.
+type Blank struct {
+	Line int // line number
+}
+
+// Comment represents a line containing nothing but a comment.
+type Comment struct {
+	Line int    // line number
+	Text string // comment text, starting with '#' or ';'
+}
+
+// Assignment represents a line setting a variable.
+type Assignment struct {
+	Line            int    // line number
+	Ident           string // identifier
+	Raw             string // value as appears in input, possibly quoted
+	Unquoted        string // value as passed to Value.Set
+	Append          bool   // "+=" rather than "="
+	Bare            bool   // identifier alone (see Options.BareFlags)
+	TrailingComment string // comment after the value, starting with '#' or ';'; or ""
+}
+
+// Directive represents a directive line, like @include "file".
+type Directive struct {
+	Line            int      // line number
+	Name            string   // directive name without '@'
+	Raw             []string // arguments as appear in input, possibly quoted
+	Args            []string // arguments after unquoting
+	TrailingComment string   // comment after the arguments, starting with '#' or ';'; or ""
+}
+
+// Section represents a section header, like [server].
+type Section struct {
+	Line            int    // line number
+	Name            string // section name, or "" for "[]"
+	TrailingComment string // comment after the header, starting with '#' or ';'; or ""
+}
+
+func (*Blank) element()      {}
+func (*Comment) element()    {}
+func (*Assignment) element() {}
+func (*Directive) element()  {}
+func (*Section) element()    {}
+
+// Document is the structure of a configuration file, including
+// comments and blank lines, in order of appearance.
+type Document struct {
+	File     string    // filename or "stdin"
+	Elements []Element // one Element per line
+
+	// text of Elements read by ReadDocument
+	src map[Element]source
+}
+
+// source is the text of an Element as read and in normalised form,
+// to tell whether it's been modified.
+type source struct {
+	orig, norm string
+}
+
+// ReadDocument reads the configuration file from r and returns its
+// structure without setting any variables or executing directives.
+// Syntax errors are reported as ParseError, like in Parse; unknown
+// or repeated identifiers are not errors, as there are no Vars to
+// check them against.
+func ReadDocument(r io.Reader, filename string) (*Document, error) {
+	return defaultOptions.ReadDocument(r, filename)
+}
+
+// ReadDocument is like the package-level ReadDocument, but modified
+// by o, e.g., to read files with SemicolonComments or BareFlags.
+func (o *Options) ReadDocument(r io.Reader, filename string) (*Document, error) {
+	p := newParser(r, filename, nil, o)
+	p.record = true
+	d := &Document{File: p.file, src: make(map[Element]source)}
+	for {
+		e, err := p.next()
+		if err == io.EOF {
+			return d, nil
+		} else if err != nil {
+			return nil, err
+		}
+		d.Elements = append(d.Elements, e)
+		d.src[e] = source{strings.Join(p.lines, "\n"), format(e)}
+	}
+}
+
+// format returns e in configuration file syntax, with whitespace
+// between tokens normalised.
+func format(e Element) string {
+	var s string
+	switch e := e.(type) {
+	case *Comment:
+		s = e.Text
+	case *Assignment:
+		s = e.Ident + " = " + e.Raw
+		if e.Append {
+			s = e.Ident + " += " + e.Raw
+		} else if e.Bare {
+			s = e.Ident
+		}
+		if e.TrailingComment != "" {
+			s += " " + e.TrailingComment
+		}
+	case *Section:
+		s = "[" + e.Name + "]"
+		if e.TrailingComment != "" {
+			s += " " + e.TrailingComment
+		}
+	case *Directive:
+		s = "@" + e.Name
+		for _, a := range e.Raw {
+			s += " " + a
+		}
+		if e.TrailingComment != "" {
+			s += " " + e.TrailingComment
+		}
+	}
+	return s
+}
+
+// WriteTo writes the document to w in configuration file syntax.
+// Elements read by ReadDocument and not modified since are written
+// as they appeared in input, including whitespace and continued
+// lines, but with line breaks written as LF.  Other Elements are
+// written with raw values and comments verbatim, while whitespace
+// between tokens is normalised.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, e := range d.Elements {
+		s := format(e)
+		if src, ok := d.src[e]; ok && src.norm == s {
+			s = src.orig
+		}
+		m, err := bw.WriteString(s + "\n")
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
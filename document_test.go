@@ -0,0 +1,85 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+const docFile = `# leading comment
+
+  x   =  1   # trailing
+y = 1\
+    2
+[ sect ]  # section
+@include   "other.conf"
+s = """
+multi
+line"""
+`
+
+func writeDoc(t *testing.T, d *Document) string {
+	var b strings.Builder
+	if _, err := d.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestDocumentLossless(t *testing.T) {
+	d, err := ReadDocument(strings.NewReader(docFile), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Elements) != 7 {
+		t.Fatalf("got %d elements, want 7", len(d.Elements))
+	}
+	if got := writeDoc(t, d); got != docFile {
+		t.Errorf("got:\n%s\nwant:\n%s", got, docFile)
+	}
+}
+
+func TestDocumentModified(t *testing.T) {
+	d, err := ReadDocument(strings.NewReader(docFile), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := d.Elements[2].(*Assignment)
+	if a.Ident != "x" || a.Unquoted != "1" || a.TrailingComment != "# trailing" {
+		t.Fatalf("got %+v", a)
+	}
+	a.Raw, a.Unquoted = "2", "2"
+	d.Elements = append(d.Elements, &Comment{Text: "# added"})
+	want := strings.Replace(docFile, "  x   =  1   # trailing",
+		"x = 2 # trailing", 1) + "# added\n"
+	if got := writeDoc(t, d); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOptionsReadDocument(t *testing.T) {
+	in := "; comment\nflag\n"
+	if _, err := ReadDocument(strings.NewReader(in), ""); err == nil {
+		t.Error("ReadDocument accepted ';' comment")
+	}
+	o := &Options{SemicolonComments: true, BareFlags: true}
+	d, err := o.ReadDocument(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := d.Elements[0].(*Comment); !ok || c.Text != "; comment" {
+		t.Errorf("got %#v, want Comment", d.Elements[0])
+	}
+	if a, ok := d.Elements[1].(*Assignment); !ok || !a.Bare {
+		t.Errorf("got %#v, want bare Assignment", d.Elements[1])
+	}
+	if got := writeDoc(t, d); got != in {
+		t.Errorf("got %q, want %q", got, in)
+	}
+}
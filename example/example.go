@@ -5,11 +5,8 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"github.com/unixdj/conf"
-	"os"
-	"regexp"
 )
 
 var (
@@ -18,21 +15,8 @@ var (
 	sval     = "default value"
 	nval     uint64
 	netKey   []byte
-	netKeyRE = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
 )
 
-type netKeyValue []byte
-
-func (key *netKeyValue) Set(s string) error {
-	if !netKeyRE.MatchString(s) {
-		return errors.New("invalid key (must be 64 hexadecimal digits)")
-	}
-	fmt.Sscanf(s, "%x", key) // will succeed
-	return nil
-}
-
-//func (key *netKeyValue) String() string { return fmt.Sprintf("%x", *key) }
-
 var vars = []conf.Var{
 	// cmd-line only:
 	//{Flag: 'h', Val: (*conf.StringValue)(&confFile)},
@@ -41,29 +25,20 @@ var vars = []conf.Var{
 	{Flag: 's', Name: "string", Val: (*conf.StringValue)(&sval)},
 	{Flag: 'n', Name: "number", Val: (*conf.Uint64Value)(&nval)},
 	{Flag: 'b', Name: "bool", Val: (*conf.BoolValue)(&bval), Kind: conf.NoArg},
-	{Flag: 'k', Name: "key", Val: (*netKeyValue)(&netKey), Required: true},
+	{Flag: 'k', Name: "key", Val: conf.NewHexBytesValue(&netKey, 32), Required: true},
 	// conf-file only:
 }
 
-func readConf(conffile string, vars []conf.Var) error {
-	f, err := os.Open(conffile)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	return conf.Parse(f, conffile, vars)
-}
-
 func main() {
 	fmt.Printf("*** start:\nconffile: %s\nstring: %s\nnumber: %d\nbool: %v\nkey: %x\n",
 		confFile, sval, nval, bval, netKey)
-	if err := conf.GetOptLong(vars); err != nil {
+	if _, err := conf.GetOptLong(vars); err != nil {
 		fmt.Printf("%s\n", err)
 		return
 	}
 	fmt.Printf("*** after GetOpt:\nconffile: %s\nstring: %s\nnumber: %d\nbool: %v\nkey: %x\n",
 		confFile, sval, nval, bval, netKey)
-	if err := readConf(confFile, vars[1:]); err != nil {
+	if err := conf.ParseFile(confFile, vars[1:]); err != nil {
 		fmt.Printf("%s\n", err)
 		return
 	}
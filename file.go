@@ -0,0 +1,115 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// include parses the file name, relative to the directory
+// of the file being parsed, with the same vars.
+func (p *parser) include(name string) error {
+	if p.open == nil {
+		return p.newError(ErrNoInclude)
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(p.path), name)
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return p.newError(err)
+	}
+	if p.open[abs] {
+		return p.newError(ErrIncludeLoop)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return p.newError(err)
+	}
+	defer f.Close()
+	q := newParser(f, name, p.vars, p.opt)
+	q.path, q.open, q.ctx = abs, p.open, p.ctx
+	p.open[abs] = true
+	defer delete(p.open, abs)
+	return q.parse()
+}
+
+// ParseFile opens the configuration file filename and parses it
+// like Parse.  If the file can't be opened, the error from os.Open
+// is returned as is, so that it can be checked with os.IsNotExist.
+//
+// Additionally, ParseFile executes include directives:
+//
+//	@include "other.conf"
+//
+// The named file is parsed with the same vars, as if its contents
+// appeared in place of the directive.  Relative names are resolved
+// relative to the directory of the including file.  Errors in included
+// files are reported with the included file's name and line numbers.
+// A file including itself, directly or indirectly, is an error.
+//
+// Files can be included conditionally, depending on the value of an
+// environment variable (see Options.Env):
+//
+//	@include-if "OS=linux" "linux.conf"
+//
+// The file is included if the variable named before the '=' has the
+// value following it, and skipped otherwise.  The condition has to be
+// quoted, as plain values can't contain '='.
+func ParseFile(filename string, vars []Var) error {
+	return defaultOptions.ParseFile(filename, vars)
+}
+
+// ParseFile is like the package-level ParseFile, but modified by o.
+func (o *Options) ParseFile(filename string, vars []Var) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p := newParser(f, filename, vars, o)
+	p.path, p.open = abs, map[string]bool{abs: true}
+	return p.run()
+}
+
+// ReloadFile is like Reload, but opens the file like ParseFile.
+func ReloadFile(filename string, vars []Var) error {
+	forgetFile(vars)
+	return ParseFile(filename, vars)
+}
+
+// Load sets vars from three layers, later ones overriding earlier:
+// the Defaults, the configuration file filename and the command line,
+// parsed with GetOptLong.  It returns the remaining command line
+// arguments.  The record of vars having been set is Reset first, so
+// Load can be called again to reload the configuration; as with
+// Reload, variables whose settings were removed from the file revert
+// to their Defaults or zero values.
+//
+// The command line is parsed first, so that the configuration file
+// doesn't override it, and the Defaults only apply to Vars set from
+// neither.  If the command line can't be parsed, the file isn't read.
+func Load(filename string, vars []Var) ([]string, error) {
+	return defaultOptions.Load(filename, vars)
+}
+
+// Load is like the package-level Load, but modified by o.
+func (o *Options) Load(filename string, vars []Var) ([]string, error) {
+	forgetFile(vars)
+	Reset(vars)
+	args, err := o.GetOptLong(vars)
+	if err != nil {
+		return args, err
+	}
+	return args, o.ParseFile(filename, vars)
+}
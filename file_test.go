@@ -0,0 +1,178 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes data to the file name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+	var s string
+	vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+	path := writeFile(t, dir, "a.conf", "s = hello\n")
+	if err := ParseFile(path, vars); err != nil {
+		t.Fatal(err)
+	}
+	if s != "hello" {
+		t.Errorf("got %q, want \"hello\"", s)
+	}
+	Reset(vars)
+	err := ParseFile(filepath.Join(dir, "missing.conf"), vars)
+	if !os.IsNotExist(err) {
+		t.Errorf("got %v, want a not-exist error", err)
+	}
+	path = writeFile(t, dir, "bad.conf", "\ns = \"\n")
+	err = ParseFile(path, vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.File != path || pe.Line != 2 {
+		t.Errorf("got %#v, want ParseError at %s:2", err, path)
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "c.conf", "c = top\n")
+	writeFile(t, dir, "sub/c.conf", "c = sub\n")
+	writeFile(t, dir, "sub/b.conf", "b = sub\n@include \"c.conf\"\n")
+	path := writeFile(t, dir, "a.conf", "a = top\n@include \"sub/b.conf\"\n")
+	var a, b, c string
+	vars := []Var{
+		{Name: "a", Val: (*StringValue)(&a)},
+		{Name: "b", Val: (*StringValue)(&b)},
+		{Name: "c", Val: (*StringValue)(&c)},
+	}
+	if err := ParseFile(path, vars); err != nil {
+		t.Fatal(err)
+	}
+	if a != "top" || b != "sub" || c != "sub" {
+		t.Errorf("got %q, %q, %q, want \"top\", \"sub\", \"sub\"",
+			a, b, c)
+	}
+}
+
+func TestIncludeLoop(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "b.conf", "@include \"a.conf\"\n")
+	path := writeFile(t, dir, "a.conf", "@include \"b.conf\"\n")
+	err := ParseFile(path, nil)
+	if !errors.Is(err, ErrIncludeLoop) {
+		t.Errorf("got %v, want ErrIncludeLoop", err)
+	}
+}
+
+func TestIncludeError(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeFile(t, dir, "bad.conf", "s = a\n\ns = \"\n")
+	path := writeFile(t, dir, "a.conf", "\n@include \"bad.conf\"\n")
+	var s string
+	vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+	err := ParseFile(path, vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.File != bad || pe.Line != 3 {
+		t.Errorf("got %#v, want ParseError at %s:3", err, bad)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "a.conf", "a = file\nb = file\n")
+	var a, b, c string
+	vars := []Var{
+		{Flag: 'a', Name: "a", Val: (*StringValue)(&a), Default: "default"},
+		{Flag: 'b', Name: "b", Val: (*StringValue)(&b), Default: "default"},
+		{Flag: 'c', Name: "c", Val: (*StringValue)(&c), Default: "default"},
+	}
+	for i := 0; i < 2; i++ {
+		withArgs([]string{"-a", "flag", "x"}, func() {
+			rest, err := Load(path, vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a != "flag" || b != "file" || c != "default" ||
+				len(rest) != 1 || rest[0] != "x" {
+				t.Errorf("Load #%d: got %q, %q, %q, %q", i+1,
+					a, b, c, rest)
+			}
+		})
+	}
+}
+
+func TestLoadRemoved(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "a.conf", "x = fromfile\ny = fromfile\n")
+	var x, y string
+	vars := []Var{
+		{Name: "x", Val: (*StringValue)(&x)},
+		{Name: "y", Val: (*StringValue)(&y), Default: "default"},
+	}
+	withArgs(nil, func() {
+		if _, err := Load(path, vars); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, dir, "a.conf", "")
+		if _, err := Load(path, vars); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if x != "" || y != "default" {
+		t.Errorf("got x=%q y=%q, want \"\", \"default\"", x, y)
+	}
+}
+
+func TestIncludeIf(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "linux.conf", "os = linux\n")
+	writeFile(t, dir, "plan9.conf", "os = plan9\n")
+	path := writeFile(t, dir, "main.conf", `@include-if "OS=linux" "linux.conf"
+@include-if "OS=plan9" "plan9.conf"
+@include-if "UNSET=" "missing.conf"
+`)
+	for _, test := range []struct {
+		env map[string]string
+		os  string
+	}{
+		{map[string]string{"OS": "linux", "UNSET": "x"}, "linux"},
+		{map[string]string{"OS": "plan9", "UNSET": "x"}, "plan9"},
+		{map[string]string{"OS": "darwin", "UNSET": "x"}, ""},
+	} {
+		var got string
+		vars := []Var{{Name: "os", Val: (*StringValue)(&got)}}
+		o := Options{Env: test.env}
+		if err := o.ParseFile(path, vars); err != nil {
+			t.Errorf("%v: %v", test.env, err)
+		} else if got != test.os {
+			t.Errorf("%v: got %q, want %q", test.env, got, test.os)
+		}
+	}
+	var s string
+	vars := []Var{{Name: "os", Val: (*StringValue)(&s)}}
+	o := Options{Env: map[string]string{"OS": "linux"}}
+	if err := o.ParseFile(path, vars); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unset variable: got %v, want missing.conf not found", err)
+	}
+	err := ParseString(`@include-if "OS=linux" "linux.conf"`, "", vars)
+	if !errors.Is(err, ErrNoInclude) {
+		t.Errorf("Parse: got %v, want ErrNoInclude", err)
+	}
+}
@@ -9,20 +9,35 @@ package conf
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"unicode/utf8"
 )
 
+// Errors returned by GetOpt, GetOptLong and GetOptLongOnly in FlagError.
+// They may be wrapped with more detail; use errors.Is to test for them.
 var (
-	errIllOpt     = errors.New("illegal option")
-	errNoArg      = errors.New("option requires an argument")
-	errEndJunk    = errors.New("junk at end of option")
-	errAlreadySet = errors.New("option already set")
+	ErrIllegalOption = errors.New("illegal option")
+	ErrNoArg         = errors.New("option requires an argument")
+	ErrEndJunk       = errors.New("junk at end of option")
+	ErrAlreadySet    = errors.New("option already set")
+	ErrAmbiguous     = errors.New("ambiguous option")
+	ErrNegate        = errors.New("only boolean options can be negated")
+	ErrPlus          = errors.New("'+' prefix requires a boolean option")
 )
 
+// ErrRepeated is returned in FlagError for a flag given twice in the
+// same argument, like "-vv", where the flag may only be set once.
+// It's wrapped with the position of the argument, counting from 1,
+// and wraps ErrAlreadySet, which is returned for flags repeated in
+// separate arguments, like "-v -v".
+var ErrRepeated = fmt.Errorf("%w: repeated within argument", ErrAlreadySet)
+
 // Args holds the command line arguments remaining after
 // GetOpt, GetOptLong or GetOptLongOnly is called.
+// The same arguments are returned by these functions; new code
+// should use the returned slice, as Args is shared by all callers.
 var Args []string
 
 // FlagError represents a command line processing error.
@@ -39,6 +54,8 @@ type FlagError struct {
 //     Err -- Flag
 // or:
 //     Err -- Long
+// followed by a suggestion for an unknown long flag, if any:
+//     illegal option -- verbsoe; did you mean '--verbose'?
 func (e *FlagError) Error() string {
 	var s string
 	switch {
@@ -49,9 +66,16 @@ func (e *FlagError) Error() string {
 	default:
 		s = string(e.Flag)
 	}
+	if se, ok := e.Err.(*suggestError); ok {
+		// suggest after the option the suggestion is for
+		return se.err.Error() + " -- " + s + se.hint()
+	}
 	return e.Err.Error() + " -- " + s
 }
 
+// Unwrap returns e.Err, so that errors.Is and errors.As can examine it.
+func (e *FlagError) Unwrap() error { return e.Err }
+
 // newError creates FlagError from f, l, v and e
 func newError(f rune, l string, v string, e error) *FlagError {
 	return &FlagError{f, l, v, e}
@@ -62,6 +86,7 @@ const (
 	short = iota
 	xLong
 	gnuLong
+	slash
 )
 
 const (
@@ -69,6 +94,7 @@ const (
 	longFlag
 	gnuLongFlag
 	falseFlag
+	slashFlag
 	endArg
 	endArgSkip
 )
@@ -79,6 +105,9 @@ func nextArg(arg string, flavour int) (int, string) {
 	}
 	switch arg[0] {
 	case '-':
+		if flavour == slash && arg != "--" {
+			break
+		}
 		if arg[1] == '-' {
 			if len(arg) == 2 {
 				return endArgSkip, ""
@@ -95,6 +124,10 @@ func nextArg(arg string, flavour int) (int, string) {
 		if flavour == xLong {
 			return falseFlag, arg[1:]
 		}
+	case '/':
+		if flavour == slash {
+			return slashFlag, arg[1:]
+		}
 	}
 	return endArg, ""
 }
@@ -108,17 +141,22 @@ func nextFlag(this string, kind int) (rune, string, string) {
 		if pos := strings.Index(this, "="); pos != -1 {
 			return '=', this[:pos], this[pos+1:]
 		}
+	case slashFlag:
+		// '=' stands for ':' here
+		if pos := strings.Index(this, ":"); pos != -1 {
+			return '=', this[:pos], this[pos+1:]
+		}
 	}
-	// longFlag or bare gnuLongFlag
+	// longFlag or bare gnuLongFlag or slashFlag
 	return 0, this, ""
 }
 
 func findFlag(flag rune, long string, kind int, vars []Var) *Var {
 	var eq func(i int) bool
 	if kind == shortFlag {
-		eq = func(i int) bool { return vars[i].Flag == flag }
+		eq = func(i int) bool { return vars[i].hasFlag(flag) }
 	} else {
-		eq = func(i int) bool { return vars[i].Name == long }
+		eq = func(i int) bool { return vars[i].hasName(long) }
 	}
 	for i := range vars {
 		if eq(i) {
@@ -128,18 +166,141 @@ func findFlag(flag rune, long string, kind int, vars []Var) *Var {
 	return nil
 }
 
-func doGetOpt(vars []Var, flavour int) error {
-	Args = make([]string, len(os.Args)-1)
-	copy(Args, os.Args[1:])
-	for len(Args) > 0 {
-		kind, this := nextArg(Args[0], flavour)
-		if kind == endArg {
+// longPrefix returns the prefix of long flags of kind.
+func longPrefix(kind int) string {
+	switch kind {
+	case gnuLongFlag:
+		return "--"
+	case longFlag:
+		return "-"
+	case slashFlag:
+		return "/"
+	}
+	return "+"
+}
+
+// abbrevFlag returns the Var whose Name long is an unambiguous
+// prefix of.
+func abbrevFlag(long string, kind int, vars []Var) (*Var, error) {
+	var (
+		v     *Var
+		n     int
+		names []string
+	)
+	for i := range vars {
+		for _, name := range vars[i].names() {
+			if name == "" || !strings.HasPrefix(name, long) {
+				continue
+			}
+			if v != &vars[i] {
+				v, n = &vars[i], n+1
+			}
+			names = append(names, longPrefix(kind)+name)
+		}
+	}
+	switch n {
+	case 0:
+		return nil, unknownFlag(long, kind, vars)
+	case 1:
+		return v, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrAmbiguous, strings.Join(names, ", "))
+}
+
+// unknownFlag returns the error for a flag not found in vars,
+// suggesting the nearest long option if there is one.
+func unknownFlag(long string, kind int, vars []Var) error {
+	if kind != gnuLongFlag && kind != longFlag && kind != slashFlag {
+		return ErrIllegalOption
+	}
+	prefix := longPrefix(kind)
+	var names []string
+	for i := range vars {
+		names = append(names, vars[i].names()...)
+	}
+	if s := suggest(long, names); s != "" {
+		return withSuggestion(ErrIllegalOption, prefix+s)
+	}
+	return ErrIllegalOption
+}
+
+// checkCluster returns errors for the short flags in this that
+// are not in vars, up to the first one taking a parameter, so that
+// no flag of an argument is set if any of them is unknown.
+func checkCluster(this string, vars []Var) []*FlagError {
+	var errs []*FlagError
+	for len(this) > 0 {
+		var flag rune
+		flag, _, this = nextFlag(this, shortFlag)
+		if flag == utf8.RuneError {
+			errs = append(errs, newError(flag, "", "", ErrSyntax))
+		} else if v := findFlag(flag, "", shortFlag, vars); v == nil {
+			errs = append(errs, newError(flag, "", "", ErrIllegalOption))
+		} else if v.Kind != NoArg {
 			break
 		}
-		Args = Args[1:]
+	}
+	return errs
+}
+
+// getOpt parses args according to vars and flavour and returns
+// a copy of the remaining arguments.  Args is kept in sync with
+// the arguments not yet processed, so Set methods may peruse it.
+func (o *Options) getOpt(args []string, vars []Var, flavour int) ([]string, error) {
+	args, errs := o.scan(args, vars, flavour, false)
+	if errs != nil {
+		return nil, errs[0]
+	}
+	return args, nil
+}
+
+// scan does the work of getOpt.  If all is true, it doesn't stop
+// at errors, but skips the offending flag and returns all errors.
+func (o *Options) scan(args []string, vars []Var, flavour int, all bool) ([]string, []*FlagError) {
+	var errs []*FlagError
+	if o.ResponseFiles {
+		var err error
+		if args, err = expandArgs(args, make(map[string]bool)); err != nil {
+			return nil, append(errs, err.(*FlagError))
+		}
+	}
+	args = append([]string(nil), args...)
+	defer func() { Args = args }()
+	var params []string // non-flag arguments skipped when permuting
+	nargs := len(args)
+	for len(args) > 0 {
+		pos := nargs - len(args) + 1
+		kind, this := nextArg(args[0], flavour)
+		if kind == endArg && o.Permute {
+			params, args = append(params, args[0]), args[1:]
+			continue
+		} else if kind == endArg {
+			break
+		}
+		args = args[1:]
 		if kind == endArgSkip {
 			break
 		}
+		// fail records the error e and reports whether to stop.
+		// Long flags are skipped with anything attached to them.
+		fail := func(e *FlagError) bool {
+			errs = append(errs, e)
+			if kind != shortFlag {
+				this = ""
+			}
+			return !all
+		}
+		inArg := make(map[*Var]bool) // Vars set by this argument
+		if kind == shortFlag {
+			if ce := checkCluster(this, vars); ce != nil {
+				for _, e := range ce {
+					if fail(e) {
+						return nil, errs
+					}
+				}
+				continue
+			}
+		}
 		for len(this) > 0 {
 			var (
 				flag    rune
@@ -147,61 +308,140 @@ func doGetOpt(vars []Var, flavour int) error {
 			)
 			flag, long, this = nextFlag(this, kind)
 			if flag == utf8.RuneError {
-				return newError(flag, long, "", errSyntax)
+				if fail(newError(flag, long, "", ErrSyntax)) {
+					return nil, errs
+				}
+				continue
 			}
 			v := findFlag(flag, long, kind, vars)
+			negate := false
+			if v == nil && kind == gnuLongFlag &&
+				strings.HasPrefix(long, "no-") {
+				v = findFlag(0, long[3:], kind, vars)
+				negate = v != nil
+			}
 			if v == nil {
-				return newError(flag, long, "", errIllOpt)
+				err := unknownFlag(long, kind, vars)
+				if o.Abbrev && kind != shortFlag {
+					v, err = abbrevFlag(long, kind, vars)
+				}
+				if err != nil {
+					if fail(newError(flag, long, "", err)) {
+						return nil, errs
+					}
+					continue
+				}
 			}
-			if v.flagSet {
-				return newError(flag, long, "", errAlreadySet)
+			if v.flagSet && !v.multiple() &&
+				!(o.LastFlagWins && v.Kind == NoArg) {
+				// skip the argument, too
+				if v.Kind == HasArg && this == "" && flag != '=' &&
+					len(args) != 0 {
+					args = args[1:]
+				}
+				this = ""
+				var e error = ErrAlreadySet
+				if inArg[v] {
+					e = fmt.Errorf("%w %d", ErrRepeated, pos)
+				}
+				if fail(newError(flag, long, "", e)) {
+					return nil, errs
+				}
+				continue
 			}
+			var err *FlagError
 			switch {
 			case kind == falseFlag:
 				if v.Kind != NoArg {
-					return newError(flag, long, "", errIllOpt)
+					err = newError(flag, long, "", ErrPlus)
+				}
+				p = "false"
+			case negate:
+				if v.Kind != NoArg {
+					err = newError(0, long, "", ErrNegate)
+				} else if flag == '=' {
+					err = newError(0, long, "", ErrEndJunk)
 				}
 				p = "false"
 			case v.Kind == NoArg:
-				if kind == gnuLongFlag && flag == '=' {
-					return newError(0, long, "", errEndJunk)
+				if kind != shortFlag && flag == '=' {
+					err = newError(0, long, "", ErrEndJunk)
 				}
 				p = "true"
 			case v.Kind == LineArg:
 				if this != "" {
 					// XXX
-					return newError(0, "", this, errEndJunk)
+					err = newError(0, "", this, ErrEndJunk)
+					this = ""
 				}
+			case v.Kind == OptionalArg:
+				// never consumes the next argument
+				p, this = this, ""
 			case this != "":
 				p, this = this, ""
-			case kind == gnuLongFlag && flag == '=':
+			case kind != shortFlag && flag == '=':
 				// empty parameter
-			case len(Args) != 0:
-				p, Args = Args[0], Args[1:]
+			case len(args) != 0:
+				p, args = args[0], args[1:]
 			default:
-				return newError(flag, long, "", errNoArg)
+				err = newError(flag, long, "", ErrNoArg)
 			}
-			if err := v.Val.Set(p); err != nil {
+			ps := []string{p}
+			if n := v.NArgs - 1; err == nil && v.Kind == HasArg && n > 0 {
+				if len(args) < n {
+					err = newError(flag, long, "", ErrNoArg)
+				} else {
+					ps, args = append(ps, args[:n]...), args[n:]
+				}
+			}
+			if err != nil {
+				if fail(err) {
+					return nil, errs
+				}
+				continue
+			}
+			if !v.flagSet {
+				v.resetVal()
+			}
+			var e error
+			for _, p = range ps {
+				Args = args
+				e = v.Val.Set(p)
+				args = Args
+				if e == nil && v.Check != nil {
+					e = v.Check(p)
+				}
+				if e != nil {
+					break
+				}
+			}
+			if e != nil {
 				if v.Kind == NoArg {
 					p = ""
 				}
-				return newError(flag, long, p, err)
+				if fail(newError(flag, long, p, e)) {
+					return nil, errs
+				}
+				continue
 			}
-			v.flagSet = true
+			v.flagSet, inArg[v] = true, true
 			if v.Kind == LineArg {
 				break
 			}
 		}
 	}
-	return nil
+	args = append(params, args...)
+	return append([]string(nil), args...), errs
 }
 
 /*
 GetOpt parses command line flags in the traditional Unix
 manner, stopping at the first unrecognized argument, without
-glibc-style flags-after-parameters bullshit.  Special
+glibc-style flags-after-parameters bullshit (unless you
+ask for it with Options.Permute).  Special
 handling of "-W" flags and getsubopt() are not supported.
-The unparsed command line arguments are kept in the Args array.
+The unparsed command line arguments are returned as a fresh slice
+and also kept in the Args array.
 
 GetOpt ignores the Name field of vars, only parsing short flags.
 
@@ -227,20 +467,48 @@ flag processing is restarted at the next character.
 For HasArg, if the rest of the argument is not empty, it becomes
 the parameter.  Otherwise the next argument is used, and
 non-existence thereof is treated as an error.  Command line
-argument processing is restarted at the next argument.
+argument processing is restarted at the next argument.  The next
+argument is used as is, even if it looks like a flag or is "--":
+"-o --" and "-o--" both set 'o' to "--", and processing continues
+after it.  Thus, negative numbers can be given either way: "-o -5",
+"-o-5", or "-bo -5" if 'b' is NoArg.
+
+A HasArg Var with NArgs greater than 1 takes that many arguments,
+counted as above, with Set called for each: "-p 3 4", "-p3 4" and
+"-bp 3 4" all set 'p' to 3 and 4 if its NArgs is 2.  Too few
+remaining arguments are an error.
 
 For LineArg, the parameter is an empty string, and the rest of
 the argument must be empty.  The Set function is expected to
 peruse Args.  Command line processing is stopped after a LineArg.
 
+For OptionalArg, the rest of the argument, if any, becomes the
+parameter; otherwise the parameter is an empty string, and the
+next argument is never consumed.  The Set function is expected
+to apply the default value when called with an empty string.
+Note that BoolValue rejects the empty string, so OptionalArg
+Vars need a Value of their own.
+
+All flags in an argument, up to the first one taking a parameter,
+are looked up before any of them is set.  If any of them is unknown,
+the whole argument is rejected: with 'a' and 'b' NoArg and 'X'
+unknown, "-abX" is an error that leaves 'a' and 'b' unset.  Errors
+from Set are only found when it's called, so "-ab" where b's Set
+fails still sets 'a'.
+
 Thus, if vars describes the flag 'n' as NoArg and 'h' as HasArg,
 the following command lines will have the identical effect:
 	./prog -n -h param -- arg0 arg1
 	./prog -nh param arg0 arg1
 	./prog -nhparam arg0 arg1
 */
-func GetOpt(vars []Var) error {
-	return doGetOpt(vars, short)
+func GetOpt(vars []Var) ([]string, error) {
+	return defaultOptions.GetOpt(vars)
+}
+
+// GetOpt is like the package-level GetOpt, but modified by o.
+func (o *Options) GetOpt(vars []Var) ([]string, error) {
+	return o.getOpt(os.Args[1:], vars, short)
 }
 
 /*
@@ -254,10 +522,16 @@ character.
 Long arguments can take the form "--name=value" or "--name".
 vars is searched for a Var whose Name is equal to the "name"
 part of the argument.
-The first form is only allowed for vars whose Kind is HasArg.
-HasArg vars of the second form use the next argument as the
-value (i.e., parameter to Value.Set).  NoArg and LineArg are
-treated as in GetOpt.
+The first form is only allowed for vars whose Kind is HasArg or
+OptionalArg.  HasArg vars of the second form use the next argument
+as the value (i.e., parameter to Value.Set), even if it starts with
+a dash, as in "--offset -5", while OptionalArg vars
+get an empty string, like in GetOpt.  NoArg and LineArg are treated
+as in GetOpt.
+
+A long argument "--no-name" for which no Var is found sets the Var
+whose Name is "name" to false (i.e., passes "false" to Value.Set),
+provided it is NoArg.
 
 Thus, if vars describes short flags 'n' (NoArg) and 'h' (HasArg)
 and a long flag "long" (HasArg),
@@ -266,8 +540,13 @@ the following command lines will have the identical effect:
 	./prog -nhparam --long very arg0 arg1
 	./prog -nhparam --long very arg0 arg1
 */
-func GetOptLong(vars []Var) error {
-	return doGetOpt(vars, gnuLong)
+func GetOptLong(vars []Var) ([]string, error) {
+	return defaultOptions.GetOptLong(vars)
+}
+
+// GetOptLong is like the package-level GetOptLong, but modified by o.
+func (o *Options) GetOptLong(vars []Var) ([]string, error) {
+	return o.getOpt(os.Args[1:], vars, gnuLong)
 }
 
 /*
@@ -275,7 +554,8 @@ GetOptLongOnly parses command line options in X11 manner, with
 long options prepended by "-" or "+", the latter to reset a
 boolean option.  It ignores the Flag field of vars, treating all
 flags as long.
-The unparsed command line arguments are kept in the Args array.
+The unparsed command line arguments are returned as a fresh slice
+and also kept in the Args array.
 
 Command line arguments parsed by GetOptLongOnly begin with a dash
 or a plus, followed by one or more characters.  The special
@@ -291,7 +571,7 @@ If none is found, an error is returned.  Otherwise, its Value.Set
 is called with a string parameter.
 
 If the argument starts with "+", the Kind of the Var must be
-NoArg.
+NoArg; otherwise ErrPlus is returned.
 
 For compatibility with BoolValue, for a Var whose Kind is NoArg,
 the parameter is "true" if the argument starts with '-' and
@@ -299,13 +579,87 @@ the parameter is "true" if the argument starts with '-' and
 slavery and backwards compatibility is good.
 For HasArg, the next argument is used as the parameter.
 For LineArg, the parameter is an empty string, and the
-command line processing stops.
+command line processing stops.  For OptionalArg, the parameter
+is always an empty string, as there's nowhere to attach a value.
 
 Thus, if vars describes long flags "t" and "f" (NoArg) and "h"
 (HasArg), the following command line will set "t" to true, "f" to
 false and "h" to "param", and leave "arg0" and "arg1" in Args:
 	./prog -t +f -h param arg0 arg1
 */
-func GetOptLongOnly(vars []Var) error {
-	return doGetOpt(vars, xLong)
+func GetOptLongOnly(vars []Var) ([]string, error) {
+	return defaultOptions.GetOptLongOnly(vars)
+}
+
+// GetOptLongPermute is like GetOptLong, but permutes arguments
+// as described under Options.Permute.
+func GetOptLongPermute(vars []Var) ([]string, error) {
+	o := Options{Permute: true}
+	return o.GetOptLong(vars)
+}
+
+// GetOptLongOnly is like the package-level GetOptLongOnly, but modified by o.
+func (o *Options) GetOptLongOnly(vars []Var) ([]string, error) {
+	return o.getOpt(os.Args[1:], vars, xLong)
+}
+
+/*
+GetOptSlash parses command line flags in the style of Windows
+programs, like GetOptLongOnly, except that flags begin with a slash
+('/') instead of a dash, and values may be attached after a colon:
+
+	prog /verbose /out:file.txt input.txt
+
+Arguments beginning with a dash, except "--", are not flags.  There's
+no '+' prefix for false.  HasArg flags take the value after the colon,
+if any, or the next argument otherwise.  For NoArg flags, the colon is
+an error.  As with GetOptLongOnly, processing stops at the first
+non-flag argument; note that an absolute path, like "/tmp", looks like
+a flag, so such arguments must follow "--".
+*/
+func GetOptSlash(vars []Var) ([]string, error) {
+	return defaultOptions.GetOptSlash(vars)
+}
+
+// GetOptSlash is like the package-level GetOptSlash, but modified by o.
+func (o *Options) GetOptSlash(vars []Var) ([]string, error) {
+	return o.getOpt(os.Args[1:], vars, slash)
+}
+
+// GetOptAll is like GetOpt, but doesn't stop at the first error.
+// Flags causing errors are skipped, along with their arguments where
+// these can be told, and processing continues, so that all mistakes
+// can be reported at once.  It returns the remaining arguments and
+// the errors, if any, in order of appearance.  Note that Vars may
+// have been set even if errors are returned.
+func GetOptAll(vars []Var) ([]string, []*FlagError) {
+	return defaultOptions.GetOptAll(vars)
+}
+
+// GetOptAll is like the package-level GetOptAll, but modified by o.
+func (o *Options) GetOptAll(vars []Var) ([]string, []*FlagError) {
+	return o.scan(os.Args[1:], vars, short, true)
+}
+
+// GetOptLongAll is like GetOptLong, but doesn't stop at the first
+// error, as described under GetOptAll.
+func GetOptLongAll(vars []Var) ([]string, []*FlagError) {
+	return defaultOptions.GetOptLongAll(vars)
+}
+
+// GetOptLongAll is like the package-level GetOptLongAll, but modified by o.
+func (o *Options) GetOptLongAll(vars []Var) ([]string, []*FlagError) {
+	return o.scan(os.Args[1:], vars, gnuLong, true)
+}
+
+// GetOptLongOnlyAll is like GetOptLongOnly, but doesn't stop at the
+// first error, as described under GetOptAll.
+func GetOptLongOnlyAll(vars []Var) ([]string, []*FlagError) {
+	return defaultOptions.GetOptLongOnlyAll(vars)
+}
+
+// GetOptLongOnlyAll is like the package-level GetOptLongOnlyAll,
+// but modified by o.
+func (o *Options) GetOptLongOnlyAll(vars []Var) ([]string, []*FlagError) {
+	return o.scan(os.Args[1:], vars, xLong, true)
 }
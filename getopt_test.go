@@ -0,0 +1,648 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// withArgs calls f with os.Args set to the program name and args.
+func withArgs(args []string, f func()) {
+	saved := os.Args
+	defer func() { os.Args = saved }()
+	os.Args = append([]string{"prog"}, args...)
+	f()
+}
+
+func TestGetOptReturnsArgs(t *testing.T) {
+	var b bool
+	vars := []Var{{Flag: 'b', Kind: NoArg, Val: (*BoolValue)(&b)}}
+	withArgs([]string{"-b", "x", "y"}, func() {
+		rest, err := GetOpt(vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"x", "y"}
+		if !reflect.DeepEqual(rest, want) || !reflect.DeepEqual(Args, want) {
+			t.Fatalf("got %q, Args %q, want %q", rest, Args, want)
+		}
+		rest[0] = "changed"
+		if os.Args[2] != "x" || Args[0] != "x" {
+			t.Error("returned slice aliases os.Args or Args")
+		}
+	})
+	if !b {
+		t.Error("-b not set")
+	}
+}
+
+// colorValue is an OptionalArg Value defaulting to "auto".
+type colorValue string
+
+func (v *colorValue) Set(s string) error {
+	if s == "" {
+		s = "auto"
+	}
+	*v = colorValue(s)
+	return nil
+}
+
+var optionalArgTests = []struct {
+	args  []string
+	color colorValue
+	rest  []string
+}{
+	{[]string{"--color"}, "auto", nil},
+	{[]string{"--color", "always"}, "auto", []string{"always"}},
+	{[]string{"--color=never"}, "never", nil},
+	{[]string{"--color="}, "auto", nil},
+	{[]string{"-C"}, "auto", nil},
+	{[]string{"-C", "x"}, "auto", []string{"x"}},
+	{[]string{"-Calways"}, "always", nil},
+	{[]string{"-vC"}, "auto", nil},
+	{[]string{"-vCnever", "x"}, "never", []string{"x"}},
+}
+
+func TestOptionalArg(t *testing.T) {
+	for _, test := range optionalArgTests {
+		var (
+			color colorValue
+			v     bool
+		)
+		vars := []Var{
+			{Flag: 'C', Name: "color", Kind: OptionalArg, Val: &color},
+			{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&v)},
+		}
+		withArgs(test.args, func() {
+			rest, err := GetOptLong(vars)
+			if err != nil {
+				t.Errorf("%q: %v", test.args, err)
+				return
+			}
+			if color != test.color || !reflect.DeepEqual(rest, test.rest) {
+				t.Errorf("%q: got %q, %q, want %q, %q", test.args,
+					color, rest, test.color, test.rest)
+			}
+		})
+	}
+}
+
+func TestCountValue(t *testing.T) {
+	for _, args := range [][]string{
+		{"-vvv"},
+		{"-v", "-v", "-v"},
+		{"-vv", "-v"},
+	} {
+		var n CountValue
+		vars := []Var{{Flag: 'v', Kind: NoArg, Val: &n}}
+		withArgs(args, func() {
+			if _, err := GetOpt(vars); err != nil {
+				t.Errorf("%q: %v", args, err)
+			} else if n != 3 {
+				t.Errorf("%q: got %d, want 3", args, n)
+			}
+		})
+	}
+}
+
+var negateTests = []struct {
+	args         []string
+	color, quiet bool
+	err          error
+}{
+	{[]string{"--color", "--no-color"}, false, false, ErrAlreadySet},
+	{[]string{"--no-color"}, false, false, nil},
+	{[]string{"--no-color", "--quiet"}, false, true, nil},
+	{[]string{"--no-verbose"}, true, false, ErrNegate},
+	{[]string{"--no-color=true"}, true, false, ErrEndJunk},
+	{[]string{"--no-such"}, true, false, ErrIllegalOption},
+}
+
+func TestNegate(t *testing.T) {
+	for _, test := range negateTests {
+		var (
+			color = true
+			quiet bool
+			level string
+		)
+		vars := []Var{
+			{Name: "color", Kind: NoArg, Val: (*BoolValue)(&color)},
+			{Name: "quiet", Kind: NoArg, Val: (*BoolValue)(&quiet)},
+			{Name: "verbose", Val: (*StringValue)(&level)},
+		}
+		withArgs(test.args, func() {
+			_, err := GetOptLong(vars)
+			if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+				t.Errorf("%q: got error %v, want %v",
+					test.args, err, test.err)
+			}
+			if err == nil && (color != test.color || quiet != test.quiet) {
+				t.Errorf("%q: got %v, %v, want %v, %v", test.args,
+					color, quiet, test.color, test.quiet)
+			}
+		})
+	}
+}
+
+var permuteTests = []struct {
+	args []string
+	v    bool
+	o    string
+	rest []string
+}{
+	{[]string{"a", "-v", "b"}, true, "", []string{"a", "b"}},
+	{[]string{"a", "b", "--verbose"}, true, "", []string{"a", "b"}},
+	{[]string{"-o", "x", "a", "b", "-v"}, true, "x", []string{"a", "b"}},
+	{[]string{"a", "--output=x", "b", "c"}, false, "x", []string{"a", "b", "c"}},
+	{[]string{"a", "--", "-v", "b"}, false, "", []string{"a", "-v", "b"}},
+	{[]string{"a", "-", "-v"}, true, "", []string{"a", "-"}},
+	{[]string{"a", "b"}, false, "", []string{"a", "b"}},
+}
+
+func TestGetOptLongPermute(t *testing.T) {
+	for _, test := range permuteTests {
+		var (
+			v bool
+			o string
+		)
+		vars := []Var{
+			{Flag: 'v', Name: "verbose", Kind: NoArg, Val: (*BoolValue)(&v)},
+			{Flag: 'o', Name: "output", Val: (*StringValue)(&o)},
+		}
+		withArgs(test.args, func() {
+			rest, err := GetOptLongPermute(vars)
+			if err != nil {
+				t.Errorf("%q: %v", test.args, err)
+			} else if v != test.v || o != test.o ||
+				!reflect.DeepEqual(rest, test.rest) {
+				t.Errorf("%q: got %v, %q, %q, want %v, %q, %q",
+					test.args, v, o, rest,
+					test.v, test.o, test.rest)
+			}
+		})
+	}
+	var v bool
+	vars := []Var{{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&v)}}
+	withArgs([]string{"a", "-v"}, func() {
+		rest, err := GetOptLong(vars)
+		if err != nil || v || len(rest) != 2 {
+			t.Errorf("GetOptLong permuted: %v, %q, %v", v, rest, err)
+		}
+	})
+}
+
+var doubleDashTests = []struct {
+	args []string
+	o    string
+	v    bool
+	rest []string
+}{
+	{[]string{"-o", "--"}, "--", false, nil},
+	{[]string{"-o--"}, "--", false, nil},
+	{[]string{"-o", "--", "-v"}, "--", true, nil},
+	{[]string{"-o--", "--", "-v"}, "--", false, []string{"-v"}},
+	{[]string{"-vo", "--", "x"}, "--", true, []string{"x"}},
+	{[]string{"--", "-o", "x"}, "", false, []string{"-o", "x"}},
+	{[]string{"-v", "--", "--"}, "", true, []string{"--"}},
+}
+
+func TestDoubleDash(t *testing.T) {
+	for _, test := range doubleDashTests {
+		var (
+			o string
+			v bool
+		)
+		vars := []Var{
+			{Flag: 'o', Val: (*StringValue)(&o)},
+			{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&v)},
+		}
+		rest, err := defaultOptions.getOpt(test.args, vars, short)
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+		} else if o != test.o || v != test.v ||
+			!reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("%q: got %q, %v, %q, want %q, %v, %q", test.args,
+				o, v, rest, test.o, test.v, test.rest)
+		}
+	}
+}
+
+func TestPlus(t *testing.T) {
+	var (
+		b bool
+		h string
+	)
+	vars := []Var{
+		{Name: "b", Kind: NoArg, Val: (*BoolValue)(&b)},
+		{Name: "h", Val: (*StringValue)(&h)},
+	}
+	_, err := defaultOptions.getOpt([]string{"-b", "+h", "x"}, vars, xLong)
+	var fe *FlagError
+	if !errors.As(err, &fe) || fe.Long != "h" || !errors.Is(err, ErrPlus) {
+		t.Fatalf("got %v, want ErrPlus for h", err)
+	}
+	if want := "'+' prefix requires a boolean option -- h"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+	Reset(vars)
+	_, err = defaultOptions.getOpt([]string{"+x"}, vars, xLong)
+	if !errors.Is(err, ErrIllegalOption) {
+		t.Errorf("got %v, want ErrIllegalOption", err)
+	}
+}
+
+var slashTests = []struct {
+	args    []string
+	verbose bool
+	out     string
+	rest    []string
+	err     error
+}{
+	{[]string{"/verbose", "a"}, true, "", []string{"a"}, nil},
+	{[]string{"/out:file.txt", "a"}, false, "file.txt", []string{"a"}, nil},
+	{[]string{"/out", "file.txt"}, false, "file.txt", nil, nil},
+	{[]string{"/out:"}, false, "", nil, nil},
+	{[]string{"/verbose", "-x", "/out:f"}, true, "", []string{"-x", "/out:f"}, nil},
+	{[]string{"/verbose", "--", "/tmp"}, true, "", []string{"/tmp"}, nil},
+	{[]string{"/bogus"}, false, "", nil, ErrIllegalOption},
+	{[]string{"/verbose:yes"}, false, "", nil, ErrEndJunk},
+	{[]string{"/out"}, false, "", nil, ErrNoArg},
+}
+
+func TestGetOptSlash(t *testing.T) {
+	for _, test := range slashTests {
+		var (
+			verbose bool
+			out     string
+		)
+		vars := []Var{
+			{Name: "verbose", Kind: NoArg, Val: (*BoolValue)(&verbose)},
+			{Name: "out", Val: (*StringValue)(&out)},
+		}
+		rest, err := defaultOptions.getOpt(test.args, vars, slash)
+		switch {
+		case test.err != nil:
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q: got %v, want %v", test.args,
+					err, test.err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", test.args, err)
+		case verbose != test.verbose || out != test.out ||
+			!reflect.DeepEqual(rest, test.rest):
+			t.Errorf("%q: got %v, %q, %q, want %v, %q, %q",
+				test.args, verbose, out, rest,
+				test.verbose, test.out, test.rest)
+		}
+	}
+}
+
+var getOptAllTests = []struct {
+	args []string
+	errs []error
+	a, b bool
+	o    string
+	rest []string
+}{
+	{[]string{"-x", "-a", "-y", "-b", "z"},
+		[]error{ErrIllegalOption, ErrIllegalOption}, true, true, "",
+		[]string{"z"}},
+	{[]string{"-axb", "--bogus", "-o", "v"},
+		[]error{ErrIllegalOption, ErrIllegalOption}, false, false, "v",
+		nil},
+	{[]string{"-a", "-a", "-o", "1", "-o", "2", "-b"},
+		[]error{ErrAlreadySet, ErrAlreadySet}, true, true, "1", nil},
+	{[]string{"--a=1", "-b", "-o"},
+		[]error{ErrEndJunk, ErrNoArg}, false, true, "", nil},
+	{[]string{"-a", "z"}, nil, true, false, "", []string{"z"}},
+}
+
+func TestGetOptAll(t *testing.T) {
+	for _, test := range getOptAllTests {
+		var (
+			a, b bool
+			o    string
+		)
+		vars := []Var{
+			{Flag: 'a', Name: "a", Kind: NoArg, Val: (*BoolValue)(&a)},
+			{Flag: 'b', Kind: NoArg, Val: (*BoolValue)(&b)},
+			{Flag: 'o', Val: (*StringValue)(&o)},
+		}
+		var (
+			rest []string
+			errs []*FlagError
+		)
+		withArgs(test.args, func() { rest, errs = GetOptLongAll(vars) })
+		if len(errs) != len(test.errs) {
+			t.Errorf("%q: got errors %v, want %v", test.args,
+				errs, test.errs)
+			continue
+		}
+		for i, err := range errs {
+			if !errors.Is(err, test.errs[i]) {
+				t.Errorf("%q: error %d: got %v, want %v",
+					test.args, i, err, test.errs[i])
+			}
+		}
+		if a != test.a || b != test.b || o != test.o ||
+			!reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("%q: got %v, %v, %q, %q, want %v, %v, %q, %q",
+				test.args, a, b, o, rest,
+				test.a, test.b, test.o, test.rest)
+		}
+	}
+}
+
+var negativeTests = []struct {
+	args    []string
+	flavour int
+	offset  int64
+	b       bool
+	rest    []string
+}{
+	{[]string{"--offset", "-5"}, gnuLong, -5, false, nil},
+	{[]string{"--offset=-5", "--", "-1"}, gnuLong, -5, false, []string{"-1"}},
+	{[]string{"-o", "-5"}, short, -5, false, nil},
+	{[]string{"-o-5"}, short, -5, false, nil},
+	{[]string{"-bo", "-5", "x"}, short, -5, true, []string{"x"}},
+	{[]string{"-bo-5"}, short, -5, true, nil},
+	{[]string{"x", "-o", "-5", "-b"}, gnuLong, -5, true, []string{"x"}},
+	{[]string{"-offset", "-5"}, xLong, -5, false, nil},
+}
+
+func TestNegativeArg(t *testing.T) {
+	for _, test := range negativeTests {
+		var (
+			offset int64
+			b      bool
+		)
+		vars := []Var{
+			{Flag: 'o', Name: "offset", Val: (*Int64Value)(&offset)},
+			{Flag: 'b', Kind: NoArg, Val: (*BoolValue)(&b)},
+		}
+		o := Options{Permute: true}
+		rest, err := o.getOpt(test.args, vars, test.flavour)
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+		} else if offset != test.offset || b != test.b ||
+			!reflect.DeepEqual(rest, test.rest) {
+			t.Errorf("%q: got %d, %v, %q, want %d, %v, %q",
+				test.args, offset, b, rest,
+				test.offset, test.b, test.rest)
+		}
+	}
+}
+
+var clusterTests = []struct {
+	args []string
+	a, b bool
+	o    string
+	err  bool
+}{
+	{[]string{"-abX"}, false, false, "", true},
+	{[]string{"-Xab"}, false, false, "", true},
+	{[]string{"-aXb"}, false, false, "", true},
+	{[]string{"-aboX"}, true, true, "X", false},
+	{[]string{"-ab", "-X"}, true, true, "", true},
+	{[]string{"-ab"}, true, true, "", false},
+}
+
+func TestCluster(t *testing.T) {
+	for _, test := range clusterTests {
+		var (
+			a, b bool
+			o    string
+		)
+		vars := []Var{
+			{Flag: 'a', Kind: NoArg, Val: (*BoolValue)(&a)},
+			{Flag: 'b', Kind: NoArg, Val: (*BoolValue)(&b)},
+			{Flag: 'o', Val: (*StringValue)(&o)},
+		}
+		_, err := defaultOptions.getOpt(test.args, vars, short)
+		if (err != nil) != test.err {
+			t.Errorf("%q: got error %v", test.args, err)
+		} else if err != nil && !errors.Is(err, ErrIllegalOption) {
+			t.Errorf("%q: got %v, want ErrIllegalOption", test.args, err)
+		}
+		if a != test.a || b != test.b || o != test.o {
+			t.Errorf("%q: got %v, %v, %q, want %v, %v, %q",
+				test.args, a, b, o, test.a, test.b, test.o)
+		}
+	}
+}
+
+var nargsTests = []struct {
+	args    []string
+	flavour int
+	point   []int
+	b       bool
+	rest    []string
+	err     error
+}{
+	{[]string{"--point", "3", "4", "x"}, gnuLong,
+		[]int{3, 4}, false, []string{"x"}, nil},
+	{[]string{"--point=3", "4"}, gnuLong, []int{3, 4}, false, nil, nil},
+	{[]string{"-p", "3", "4"}, short, []int{3, 4}, false, nil, nil},
+	{[]string{"-p3", "4"}, short, []int{3, 4}, false, nil, nil},
+	{[]string{"-bp", "3", "4"}, short, []int{3, 4}, true, nil, nil},
+	{[]string{"-bp", "3", "-4", "y"}, short,
+		[]int{3, -4}, true, []string{"y"}, nil},
+	{[]string{"-p", "3"}, short, nil, false, nil, ErrNoArg},
+	{[]string{"--point", "3"}, gnuLong, nil, false, nil, ErrNoArg},
+}
+
+func TestNArgs(t *testing.T) {
+	for _, test := range nargsTests {
+		var (
+			point []int
+			b     bool
+		)
+		vars := []Var{
+			{Flag: 'p', Name: "point", NArgs: 2,
+				Val: NewIntSliceValue(&point)},
+			{Flag: 'b', Kind: NoArg, Val: (*BoolValue)(&b)},
+		}
+		rest, err := defaultOptions.getOpt(test.args, vars, test.flavour)
+		switch {
+		case test.err != nil:
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q: got %v, want %v", test.args,
+					err, test.err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", test.args, err)
+		case !reflect.DeepEqual(point, test.point) || b != test.b ||
+			!reflect.DeepEqual(rest, test.rest):
+			t.Errorf("%q: got %v, %v, %q, want %v, %v, %q",
+				test.args, point, b, rest,
+				test.point, test.b, test.rest)
+		}
+	}
+}
+
+var lastFlagWinsTests = []struct {
+	args    []string
+	flavour int
+	b       bool
+	err     error
+}{
+	{[]string{"-b", "+b"}, xLong, false, nil},
+	{[]string{"+b", "-b"}, xLong, true, nil},
+	{[]string{"--b", "--no-b"}, gnuLong, false, nil},
+	{[]string{"--no-b", "--b"}, gnuLong, true, nil},
+	{[]string{"-b", "-b"}, short, true, nil},
+	{[]string{"--s=x", "--s=y"}, gnuLong, false, ErrAlreadySet},
+}
+
+func TestLastFlagWins(t *testing.T) {
+	for _, test := range lastFlagWinsTests {
+		var (
+			b bool
+			s string
+		)
+		vars := []Var{
+			{Flag: 'b', Name: "b", Kind: NoArg, Val: (*BoolValue)(&b)},
+			{Name: "s", Val: (*StringValue)(&s)},
+		}
+		o := Options{LastFlagWins: true}
+		_, err := o.getOpt(test.args, vars, test.flavour)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%q: got error %v, want %v", test.args, err, test.err)
+		} else if b != test.b {
+			t.Errorf("%q: got %v, want %v", test.args, b, test.b)
+		}
+		Reset(vars)
+		_, err = defaultOptions.getOpt(test.args, vars, test.flavour)
+		if !errors.Is(err, ErrAlreadySet) {
+			t.Errorf("%q: default: got %v, want ErrAlreadySet",
+				test.args, err)
+		}
+	}
+}
+
+var repeatedTests = []struct {
+	args     []string
+	msg      string
+	repeated bool
+}{
+	{[]string{"-vv"},
+		"option already set: repeated within argument 1 -- v", true},
+	{[]string{"x", "-bvv"},
+		"option already set: repeated within argument 2 -- v", true},
+	{[]string{"-b", "-vv"},
+		"option already set: repeated within argument 2 -- v", true},
+	{[]string{"-v", "-v"}, "option already set -- v", false},
+	{[]string{"-v", "x", "-bv"}, "option already set -- v", false},
+}
+
+func TestRepeated(t *testing.T) {
+	for _, test := range repeatedTests {
+		var v, b bool
+		vars := []Var{
+			{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&v)},
+			{Flag: 'b', Kind: NoArg, Val: (*BoolValue)(&b)},
+		}
+		o := Options{Permute: true}
+		_, err := o.getOpt(test.args, vars, short)
+		if !errors.Is(err, ErrAlreadySet) ||
+			errors.Is(err, ErrRepeated) != test.repeated {
+			t.Errorf("%q: got %v", test.args, err)
+		} else if err.Error() != test.msg {
+			t.Errorf("%q: got %q, want %q", test.args, err, test.msg)
+		}
+	}
+}
+
+var abbrevTests = []struct {
+	args    []string
+	flavour int
+	set     string
+	msg     string
+}{
+	{[]string{"--col"}, gnuLong, "color", ""},
+	{[]string{"--verbo"}, gnuLong, "verbose", ""},
+	{[]string{"--verb"}, gnuLong, "verb", ""},
+	{[]string{"--ver"}, gnuLong, "",
+		"ambiguous option: --verb, --verbose, --version -- ver"},
+	{[]string{"-verbo"}, xLong, "verbose", ""},
+	{[]string{"-verb"}, xLong, "verb", ""},
+	{[]string{"-ver"}, xLong, "",
+		"ambiguous option: -verb, -verbose, -version -- ver"},
+	{[]string{"--xyz"}, gnuLong, "", "illegal option -- xyz"},
+}
+
+func TestAbbrev(t *testing.T) {
+	for _, test := range abbrevTests {
+		var verb, verbose, version, color bool
+		vars := []Var{
+			{Name: "verb", Kind: NoArg, Val: (*BoolValue)(&verb)},
+			{Name: "verbose", Kind: NoArg, Val: (*BoolValue)(&verbose)},
+			{Name: "version", Kind: NoArg, Val: (*BoolValue)(&version)},
+			{Name: "color", Kind: NoArg, Val: (*BoolValue)(&color)},
+		}
+		o := Options{Abbrev: true}
+		_, err := o.getOpt(test.args, vars, test.flavour)
+		if test.msg != "" {
+			if err == nil || err.Error() != test.msg {
+				t.Errorf("%q: got %v, want %q", test.args, err, test.msg)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+			continue
+		}
+		for i := range vars {
+			if set := WasSet(&vars[i]); set != (vars[i].Name == test.set) {
+				t.Errorf("%q: %s set = %v", test.args, vars[i].Name, set)
+			}
+		}
+	}
+}
+
+func TestAbbrevAmbiguous(t *testing.T) {
+	vars := []Var{
+		{Name: "verbose", Kind: NoArg, Val: new(BoolValue)},
+		{Name: "version", Kind: NoArg, Val: new(BoolValue)},
+	}
+	_, err := abbrevFlag("ver", gnuLongFlag, vars)
+	if !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("got %v, want ErrAmbiguous", err)
+	}
+	if v, err := abbrevFlag("verb", gnuLongFlag, vars); err != nil ||
+		v != &vars[0] {
+		t.Errorf("verb: got %v, %v, want verbose", v, err)
+	}
+}
+
+var suggestFlagTests = []struct {
+	args    []string
+	flavour int
+	msg     string
+}{
+	{[]string{"--verbsoe"}, gnuLong,
+		"illegal option -- verbsoe; did you mean '--verbose'?"},
+	{[]string{"-verbsoe"}, xLong,
+		"illegal option -- verbsoe; did you mean '-verbose'?"},
+	{[]string{"--xyz"}, gnuLong, "illegal option -- xyz"},
+}
+
+func TestSuggestFlag(t *testing.T) {
+	for _, test := range suggestFlagTests {
+		vars := []Var{
+			{Name: "verbose", Kind: NoArg, Val: new(BoolValue)},
+			{Name: "color", Kind: NoArg, Val: new(BoolValue)},
+		}
+		_, err := defaultOptions.getOpt(test.args, vars, test.flavour)
+		if !errors.Is(err, ErrIllegalOption) || err.Error() != test.msg {
+			t.Errorf("%q: got %v, want %q", test.args, err, test.msg)
+		}
+	}
+}
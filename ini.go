@@ -0,0 +1,66 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// scanINIValue returns the unquoted value at the start of line,
+// which follows whitespace, so it extends to the end of line or to
+// a comment starting with '#' or ';' at the start of line or after
+// whitespace, without trailing whitespace.
+func scanINIValue(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] != '#' && line[i] != ';' {
+			continue
+		}
+		r, _ := utf8.DecodeLastRuneInString(line[:i])
+		if i == 0 || unicode.IsSpace(r) {
+			line = line[:i]
+			break
+		}
+	}
+	return strings.TrimRightFunc(line, unicode.IsSpace)
+}
+
+/*
+ParseINI parses an INI file from r like Parse, for importing existing
+configuration files.  The syntax is that of configuration files with
+Options.SemicolonComments, except for unquoted values, which extend
+to the end of line or to a comment, and may contain any characters,
+e.g.:
+
+	; database settings
+	[db]
+	host = db.example.com
+	dsn  = user=app password=secret  # trailing comment
+
+	[log]
+	path = C:\Program Files\App\app.log
+
+Sections map to dotted names as usual, so "host" above sets the Var
+named "db.host".  Whitespace around unquoted values is ignored; they
+may be empty.  Values starting with '"' are quoted as usual, and
+a backslash at the end of line still continues it.
+*/
+func ParseINI(r io.Reader, filename string, vars []Var) error {
+	return defaultOptions.ParseINI(r, filename, vars)
+}
+
+// ParseINI is like the package-level ParseINI, but modified by o.
+func (o *Options) ParseINI(r io.Reader, filename string, vars []Var) error {
+	opt := *o
+	opt.SemicolonComments = true
+	p := newParser(r, filename, vars, &opt)
+	p.ini = true
+	return p.run()
+}
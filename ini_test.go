@@ -0,0 +1,82 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+const iniFile = `; database settings
+[db]
+host = db.example.com
+port = 5432 ; comment
+dsn  = user=app password=secret  # trailing comment
+
+[log] ; logs
+path = C:\Program Files\App\app.log
+empty =
+semi = ; comment
+hash =   # comment
+quoted = " a "
+`
+
+func TestParseINI(t *testing.T) {
+	var (
+		host, dsn, path, qut string
+		empty, semi, hash    = "x", "x", "x"
+		port                 int64
+	)
+	vars := []Var{
+		{Name: "db.host", Val: (*StringValue)(&host)},
+		{Name: "db.port", Val: (*Int64Value)(&port)},
+		{Name: "db.dsn", Val: (*StringValue)(&dsn)},
+		{Name: "log.path", Val: (*StringValue)(&path)},
+		{Name: "log.empty", Val: (*StringValue)(&empty)},
+		{Name: "log.semi", Val: (*StringValue)(&semi)},
+		{Name: "log.hash", Val: (*StringValue)(&hash)},
+		{Name: "log.quoted", Val: (*StringValue)(&qut)},
+	}
+	if err := ParseINI(strings.NewReader(iniFile), "x.ini", vars); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ name, got, want string }{
+		{"host", host, "db.example.com"},
+		{"dsn", dsn, "user=app password=secret"},
+		{"path", path, `C:\Program Files\App\app.log`},
+		{"empty", empty, ""},
+		{"semi", semi, ""},
+		{"hash", hash, ""},
+		{"quoted", qut, " a "},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, c.got, c.want)
+		}
+	}
+	if port != 5432 {
+		t.Errorf("port: got %d, want 5432", port)
+	}
+}
+
+var scanINIValueTests = []struct {
+	in, out string
+}{
+	{"là;y", "là;y"},
+	{"Å#y", "Å#y"},
+	{"là ;y", "là"},
+	{"là # y", "là"},
+	{"#y", ""},
+}
+
+func TestScanINIValue(t *testing.T) {
+	for _, test := range scanINIValueTests {
+		if s := scanINIValue(test.in); s != test.out {
+			t.Errorf("%q: got %q, want %q", test.in, s, test.out)
+		}
+	}
+}
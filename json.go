@@ -0,0 +1,73 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import "encoding/json"
+
+// jsonValue returns the current value of v as a JSON-encodable
+// value: numbers, booleans and lists for the Value types holding
+// them, or the string returned by ValueString otherwise.  Values
+// with no String method are encoded as null.
+func jsonValue(v Value) interface{} {
+	switch v := v.(type) {
+	case *StringValue:
+		return string(*v)
+	case *BoolValue:
+		return bool(*v)
+	case *Int64Value:
+		return int64(*v)
+	case *IntValue:
+		return int(*v)
+	case *DecimalInt64Value:
+		return int64(*v)
+	case *Uint64Value:
+		return uint64(*v)
+	case *CountValue:
+		return int(*v)
+	case *ByteSizeValue:
+		return int64(*v)
+	case *IntRangeValue:
+		return *v.target
+	case *UintRangeValue:
+		return *v.target
+	case *UnitValue:
+		return *v.target
+	case *PercentValue:
+		return *v.target
+	case *StringSliceValue:
+		return []string(*v)
+	case *IntSliceValue:
+		return *v.target
+	case *MapValue:
+		return *v.target
+	case *OptionalValue:
+		return jsonValue(v.Value)
+	}
+	if s, ok := ValueString(v); ok {
+		return s
+	}
+	return nil
+}
+
+// MarshalJSON returns the current values of vars as a JSON object
+// mapping each Var's Name to its value, for diagnostics.  Numeric,
+// boolean and list Values of this package are encoded as JSON
+// numbers, booleans and arrays, MapValue as an object, and other
+// Values as the string returned by String, or null if there's no
+// String method.  Vars with empty Name are omitted.
+//
+// Unlike Write, MarshalJSON's output can't be parsed back into vars.
+func MarshalJSON(vars []Var) ([]byte, error) {
+	m := make(map[string]interface{})
+	for i := range vars {
+		if vars[i].Name != "" {
+			m[vars[i].Name] = jsonValue(vars[i].Val)
+		}
+	}
+	return json.Marshal(m)
+}
@@ -0,0 +1,59 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	var (
+		s     = StringValue("str")
+		b     = BoolValue(true)
+		n     = Int64Value(-42)
+		u     = Uint64Value(1 << 63)
+		c     = CountValue(3)
+		size  = ByteSizeValue(1024)
+		d     = DurationValue(time.Second)
+		l     = StringSliceValue{"a", "b"}
+		ints  = []int{1, 2}
+		port  = int64(8080)
+		ratio = 0.5
+		str   string
+		m     = map[string]string{"k": "v"}
+	)
+	vars := []Var{
+		{Name: "s", Val: &s},
+		{Name: "b", Val: &b},
+		{Name: "n", Val: &n},
+		{Name: "u", Val: &u},
+		{Name: "c", Val: &c},
+		{Name: "size", Val: &size},
+		{Name: "d", Val: &d},
+		{Name: "l", Val: &l},
+		{Name: "ints", Val: NewIntSliceValue(&ints)},
+		{Name: "port", Val: NewIntRangeValue(&port, 1, 65535)},
+		{Name: "opt", Val: NewOptionalValue((*Int64Value)(&port))},
+		{Name: "ratio", Val: NewPercentValue(&ratio)},
+		{Name: "m", Val: NewMapValue(&m)},
+		{Name: "f", Val: setOnly{&str}},
+		{Flag: 'v', Val: &b},
+	}
+	got, err := MarshalJSON(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"b":true,"c":3,"d":"1s","f":null,"ints":[1,2],` +
+		`"l":["a","b"],"m":{"k":"v"},"n":-42,"opt":8080,` +
+		`"port":8080,"ratio":0.5,"s":"str","size":1024,` +
+		`"u":9223372036854775808}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
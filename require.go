@@ -0,0 +1,141 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by AtLeastOneOf, AtMostOneOf, RequireOneOf and Requires.
+var (
+	ErrNoneSet  = errors.New("one of these must be set")
+	ErrConflict = errors.New("mutually exclusive")
+	ErrRequires = errors.New("requires")
+)
+
+// findVar returns the Var named name, where a Var with no Name
+// is named by its Flag preceded by '-'.
+func findVar(vars []Var, name string) (*Var, error) {
+	for i := range vars {
+		if label(vars, i) == name {
+			return &vars[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", name, ErrUnknownVar)
+}
+
+// setOf returns the names of the Vars named by names that were set.
+func setOf(vars []Var, names []string) ([]string, error) {
+	var set []string
+	for _, name := range names {
+		v, err := findVar(vars, name)
+		if err != nil {
+			return nil, err
+		}
+		if WasSet(v) {
+			set = append(set, name)
+		}
+	}
+	return set, nil
+}
+
+// AtLeastOneOf returns an error wrapping ErrNoneSet unless at least
+// one of the Vars named by names was set, as reported by WasSet.
+// Like in Validate's error messages, a Var with no Name is named
+// by its Flag preceded by '-', like "-v".  Naming a Var not in vars
+// is an error.  As it relies on WasSet, AtLeastOneOf, like AtMostOneOf
+// and RequireOneOf, must be called after parsing.
+func AtLeastOneOf(vars []Var, names ...string) error {
+	set, err := setOf(vars, names)
+	if err == nil && len(set) == 0 {
+		err = fmt.Errorf("%w: %s", ErrNoneSet, strings.Join(names, ", "))
+	}
+	return err
+}
+
+// AtMostOneOf returns an error wrapping ErrConflict if more than one
+// of the Vars named by names was set.
+func AtMostOneOf(vars []Var, names ...string) error {
+	set, err := setOf(vars, names)
+	if err == nil && len(set) > 1 {
+		err = fmt.Errorf("%w: %s", ErrConflict, strings.Join(set, ", "))
+	}
+	return err
+}
+
+// RequireOneOf returns an error unless exactly one of the Vars named
+// by names was set, e.g., for the command line options "--file" and
+// "--stdin", only one of which makes sense.
+func RequireOneOf(vars []Var, names ...string) error {
+	if err := AtLeastOneOf(vars, names...); err != nil {
+		return err
+	}
+	return AtMostOneOf(vars, names...)
+}
+
+// Requires returns an error wrapping ErrRequires if the Var named
+// ifName was set, but any of the Vars named by thenNames wasn't,
+// e.g., for "--tls-cert" requiring "--tls":
+//
+//	conf.Requires(vars, "tls-cert", "tls")
+func Requires(vars []Var, ifName string, thenNames ...string) error {
+	set, err := setOf(vars, []string{ifName})
+	if err != nil || len(set) == 0 {
+		return err
+	}
+	var missing []string
+	for _, name := range thenNames {
+		v, err := findVar(vars, name)
+		if err != nil {
+			return err
+		}
+		if !WasSet(v) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("%s %w %s", ifName, ErrRequires,
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ErrArgCount is returned by RequireArgs and RequireArgsIn.
+// It may be wrapped, so use errors.Is to check for it.
+var ErrArgCount = errors.New("wrong number of arguments")
+
+// RequireArgs returns an error wrapping ErrArgCount unless the number
+// of command line arguments remaining in Args is from min to max,
+// inclusive.  A max of -1 means there's no upper bound.  For example,
+// after GetOpt, a program taking one or two file names would call:
+//
+//	conf.RequireArgs(1, 2)
+func RequireArgs(min, max int) error {
+	return RequireArgsIn(Args, min, max)
+}
+
+// RequireArgsIn is like RequireArgs, but checks args instead of Args,
+// e.g., the slice returned by GetOpt.
+func RequireArgsIn(args []string, min, max int) error {
+	n := len(args)
+	if n >= min && (max == -1 || n <= max) {
+		return nil
+	}
+	var want string
+	switch {
+	case max == -1:
+		want = fmt.Sprintf("at least %d", min)
+	case min == max:
+		want = fmt.Sprint(min)
+	default:
+		want = fmt.Sprintf("between %d and %d", min, max)
+	}
+	return fmt.Errorf("%w: expected %s, got %d", ErrArgCount, want, n)
+}
@@ -0,0 +1,148 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"testing"
+)
+
+// requireVars returns Vars for the NoArg long flags "file", "stdin",
+// "tls" and "tls-cert" and the short flag 'v'.
+func requireVars() []Var {
+	vars := []Var{
+		{Name: "file"}, {Name: "stdin"},
+		{Name: "tls"}, {Name: "tls-cert"}, {Flag: 'v'},
+	}
+	for i := range vars {
+		vars[i].Kind, vars[i].Val = NoArg, new(BoolValue)
+	}
+	return vars
+}
+
+var oneOfTests = []struct {
+	args                    []string
+	least, most, exactlyOne error
+}{
+	{nil, ErrNoneSet, nil, ErrNoneSet},
+	{[]string{"--file"}, nil, nil, nil},
+	{[]string{"-v"}, nil, nil, nil},
+	{[]string{"--file", "-v"}, nil, ErrConflict, ErrConflict},
+	{[]string{"--file", "--stdin", "-v"}, nil, ErrConflict, ErrConflict},
+	{[]string{"--tls"}, ErrNoneSet, nil, ErrNoneSet},
+}
+
+func TestOneOf(t *testing.T) {
+	for _, test := range oneOfTests {
+		vars := requireVars()
+		_, err := defaultOptions.getOpt(test.args, vars, gnuLong)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range []struct {
+			name string
+			f    func([]Var, ...string) error
+			want error
+		}{
+			{"AtLeastOneOf", AtLeastOneOf, test.least},
+			{"AtMostOneOf", AtMostOneOf, test.most},
+			{"RequireOneOf", RequireOneOf, test.exactlyOne},
+		} {
+			err := f.f(vars, "file", "stdin", "-v")
+			if !errors.Is(err, f.want) || (err == nil) != (f.want == nil) {
+				t.Errorf("%s %q: got %v, want %v", f.name,
+					test.args, err, f.want)
+			}
+		}
+	}
+	err := AtLeastOneOf(requireVars(), "file", "bogus")
+	if !errors.Is(err, ErrUnknownVar) {
+		t.Errorf("unknown name: got %v, want ErrUnknownVar", err)
+	}
+}
+
+var requiresTests = []struct {
+	args []string
+	err  error
+}{
+	{nil, nil},
+	{[]string{"--tls"}, nil},
+	{[]string{"--tls", "--tls-cert"}, nil},
+	{[]string{"--tls-cert", "--tls"}, nil},
+	{[]string{"--tls-cert"}, ErrRequires},
+	{[]string{"--tls-cert", "-v"}, ErrRequires},
+	{[]string{"--tls", "-v"}, nil},
+}
+
+func TestRequires(t *testing.T) {
+	for _, test := range requiresTests {
+		vars := requireVars()
+		_, err := defaultOptions.getOpt(test.args, vars, gnuLong)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Requires(vars, "tls-cert", "tls")
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%q: got %v, want %v", test.args, err, test.err)
+		}
+	}
+	vars := requireVars()
+	_, err := defaultOptions.getOpt([]string{"--tls-cert"}, vars, gnuLong)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "tls-cert requires tls, -v"
+	err = Requires(vars, "tls-cert", "tls", "-v")
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+	err = Requires(vars, "tls-cert", "bogus")
+	if !errors.Is(err, ErrUnknownVar) {
+		t.Errorf("unknown name: got %v, want ErrUnknownVar", err)
+	}
+}
+
+var requireArgsTests = []struct {
+	n, min, max int
+	msg         string
+}{
+	{0, 1, 2, "wrong number of arguments: expected between 1 and 2, got 0"},
+	{1, 1, 2, ""},
+	{2, 1, 2, ""},
+	{3, 1, 2, "wrong number of arguments: expected between 1 and 2, got 3"},
+	{0, 1, 1, "wrong number of arguments: expected 1, got 0"},
+	{1, 1, 1, ""},
+	{0, 0, 0, ""},
+	{5, 2, -1, ""},
+	{1, 2, -1, "wrong number of arguments: expected at least 2, got 1"},
+}
+
+func TestRequireArgs(t *testing.T) {
+	for _, test := range requireArgsTests {
+		args := make([]string, test.n)
+		err := RequireArgsIn(args, test.min, test.max)
+		if test.msg == "" && err != nil {
+			t.Errorf("%d in [%d, %d]: %v", test.n, test.min, test.max, err)
+		} else if test.msg != "" &&
+			(!errors.Is(err, ErrArgCount) || err.Error() != test.msg) {
+			t.Errorf("%d in [%d, %d]: got %v, want %q",
+				test.n, test.min, test.max, err, test.msg)
+		}
+	}
+	withArgs([]string{"a", "b"}, func() {
+		if _, err := GetOpt(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := RequireArgs(2, 2); err != nil {
+			t.Error(err)
+		}
+		if err := RequireArgs(0, 1); !errors.Is(err, ErrArgCount) {
+			t.Errorf("got %v, want ErrArgCount", err)
+		}
+	})
+}
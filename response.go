@@ -0,0 +1,103 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+var errUnclosedQuote = errors.New("unterminated quote")
+
+// splitArgs splits s into arguments separated by whitespace.
+// Within an argument, characters between single quotes are taken
+// literally, characters between double quotes are taken literally
+// except for backslash escaping '"' and '\', and backslash outside
+// quotes escapes any character.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		arg   []rune
+		in    bool // inside an argument
+		quote rune // current quote character, or 0
+		esc   bool // previous character was an escaping backslash
+	)
+	for _, c := range s {
+		switch {
+		case esc:
+			if quote == '"' && c != '"' && c != '\\' {
+				arg = append(arg, '\\')
+			}
+			arg, esc = append(arg, c), false
+		case c == '\\' && quote != '\'':
+			in, esc = true, true
+		case c == quote:
+			quote = 0
+		case quote != 0:
+			arg = append(arg, c)
+		case c == '"' || c == '\'':
+			in, quote = true, c
+		case unicode.IsSpace(c):
+			if in {
+				args, arg, in = append(args, string(arg)), arg[:0], false
+			}
+		default:
+			in, arg = true, append(arg, c)
+		}
+	}
+	if quote != 0 || esc {
+		return nil, errUnclosedQuote
+	}
+	if in {
+		args = append(args, string(arg))
+	}
+	return args, nil
+}
+
+// expandArgs returns args with response files expanded as described
+// under Options.ResponseFiles.  open holds the absolute names of
+// response files being expanded.
+func expandArgs(args []string, open map[string]bool) ([]string, error) {
+	var l []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "@@"):
+			l = append(l, arg[1:])
+			continue
+		case !strings.HasPrefix(arg, "@") || arg == "@":
+			l = append(l, arg)
+			continue
+		}
+		abs, err := filepath.Abs(arg[1:])
+		if err != nil {
+			return nil, newError(0, "", arg, err)
+		}
+		if open[abs] {
+			return nil, newError(0, "", arg, ErrIncludeLoop)
+		}
+		data, err := ioutil.ReadFile(arg[1:])
+		if err != nil {
+			return nil, newError(0, "", arg, err)
+		}
+		more, err := splitArgs(string(data))
+		if err != nil {
+			return nil, newError(0, "", arg, err)
+		}
+		open[abs] = true
+		more, err = expandArgs(more, open)
+		delete(open, abs)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, more...)
+	}
+	return l, nil
+}
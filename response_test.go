@@ -0,0 +1,83 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var splitArgsTests = []struct {
+	in   string
+	args []string
+	ok   bool
+}{
+	{"", nil, true},
+	{" a\tb\n\nc ", []string{"a", "b", "c"}, true},
+	{`"two words" 'single quoted'`, []string{"two words", "single quoted"}, true},
+	{`a"b c"d`, []string{"ab cd"}, true},
+	{`"" ''`, []string{"", ""}, true},
+	{`"a \"b\" \\ \n"`, []string{`a "b" \ \n`}, true},
+	{`'a \ "b"'`, []string{`a \ "b"`}, true},
+	{`a\ b \'c`, []string{"a b", "'c"}, true},
+	{`"unclosed`, nil, false},
+	{`'unclosed`, nil, false},
+	{`trailing\`, nil, false},
+}
+
+func TestSplitArgs(t *testing.T) {
+	for _, test := range splitArgsTests {
+		args, err := splitArgs(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v", test.in, err)
+		} else if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%q: got %q, want %q", test.in, args, test.args)
+		}
+	}
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	inner := writeFile(t, dir, "inner", "-v 'x y'\n")
+	outer := writeFile(t, dir, "outer", `-o "out file" @`+inner+" arg\n")
+	loop := writeFile(t, dir, "loop", "@"+dir+"/loop\n")
+	var (
+		o string
+		v bool
+	)
+	vars := []Var{
+		{Flag: 'o', Val: (*StringValue)(&o)},
+		{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&v)},
+	}
+	opt := &Options{ResponseFiles: true}
+	rest, err := opt.getOpt([]string{"@" + outer, "@@at", "@"}, vars, short)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"x y", "arg", "@at", "@"}
+	if o != "out file" || !v || !reflect.DeepEqual(rest, want) {
+		t.Errorf("got %q, %v, %q, want \"out file\", true, %q",
+			o, v, rest, want)
+	}
+
+	Reset(vars)
+	rest, err = defaultOptions.getOpt([]string{"@" + outer}, vars, short)
+	if err != nil || len(rest) != 1 || rest[0] != "@"+outer {
+		t.Errorf("disabled: got %q, %v", rest, err)
+	}
+	Reset(vars)
+	_, err = opt.getOpt([]string{"@" + loop}, vars, short)
+	if !errors.Is(err, ErrIncludeLoop) {
+		t.Errorf("loop: got %v, want ErrIncludeLoop", err)
+	}
+	_, err = opt.getOpt([]string{"@" + dir + "/missing"}, vars, short)
+	if err == nil {
+		t.Error("missing file: no error")
+	}
+}
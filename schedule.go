@@ -0,0 +1,110 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule describes when a recurring event, like key rotation,
+// happens: either at a fixed Interval, or at the start of every
+// calendar period named by Period.
+type Schedule struct {
+	Interval time.Duration // fixed interval, or 0
+	Period   string        // "daily", "weekly", "monthly", or ""
+}
+
+// Next returns the time of the first event after t.
+// For fixed intervals, that's t plus Interval.  For calendar periods,
+// it's midnight starting the next day, week (Monday) or month, in t's
+// location; these are not fixed durations because of daylight saving
+// time and months of different lengths.
+func (s Schedule) Next(t time.Time) time.Time {
+	if s.Period == "" {
+		return t.Add(s.Interval)
+	}
+	y, m, d := t.Date()
+	switch s.Period {
+	case "weekly":
+		n := (int(time.Monday) - int(t.Weekday()) + 7) % 7
+		if n == 0 {
+			n = 7
+		}
+		d += n
+	case "monthly":
+		m, d = m+1, 1
+	default: // daily
+		d++
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+var periods = []string{"daily", "weekly", "monthly"}
+
+// dayUnitRE matches a number followed by a day or week unit.
+var dayUnitRE = regexp.MustCompile(`([0-9]+(?:\.[0-9]*)?|\.[0-9]+)([dw])`)
+
+// parseDuration is like time.ParseDuration, but also accepts
+// the units "d" (day, 24h) and "w" (week, 7d).
+func parseDuration(s string) (time.Duration, error) {
+	s = dayUnitRE.ReplaceAllStringFunc(s, func(m string) string {
+		f, _ := strconv.ParseFloat(m[:len(m)-1], 64)
+		if m[len(m)-1] == 'w' {
+			f *= 7
+		}
+		return strconv.FormatFloat(f*24, 'f', -1, 64) + "h"
+	})
+	return time.ParseDuration(s)
+}
+
+// DurationValue represents a configuration variable's time.Duration
+// value.  Syntax: as accepted by time.ParseDuration, with the
+// additional units "d" (24h) and "w" (7d), like "1h30m" or "2w".
+type DurationValue time.Duration
+
+func (v *DurationValue) Set(s string) error {
+	d, err := parseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = DurationValue(d)
+	return nil
+}
+
+func (v *DurationValue) String() string { return time.Duration(*v).String() }
+
+// ScheduleValue represents a configuration variable's Schedule value.
+// Syntax: a positive duration as accepted by time.ParseDuration with
+// the additional units "d" (24h) and "w" (7d), like "90d" or "1w12h";
+// or one of "daily", "weekly" and "monthly" (case insensitive).
+type ScheduleValue Schedule
+
+func (v *ScheduleValue) Set(s string) error {
+	if strInList(s, periods) {
+		*v = ScheduleValue{Period: strings.ToLower(s)}
+		return nil
+	}
+	d, err := parseDuration(s)
+	if err != nil || d <= 0 {
+		return errors.New("invalid schedule, must be a positive " +
+			"duration or one of: " + strings.Join(periods, ", "))
+	}
+	*v = ScheduleValue{Interval: d}
+	return nil
+}
+
+func (v *ScheduleValue) String() string {
+	if v.Period != "" {
+		return v.Period
+	}
+	return v.Interval.String()
+}
@@ -0,0 +1,115 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d, h int) time.Time {
+	return time.Date(y, m, d, h, 0, 0, 0, time.UTC)
+}
+
+var nextTests = []struct {
+	s       Schedule
+	t, want time.Time
+}{
+	{Schedule{Interval: 90 * time.Minute}, date(2024, 1, 1, 10),
+		date(2024, 1, 1, 10).Add(90 * time.Minute)},
+	{Schedule{Period: "daily"}, date(2024, 2, 28, 10), date(2024, 2, 29, 0)},
+	{Schedule{Period: "daily"}, date(2024, 12, 31, 0), date(2025, 1, 1, 0)},
+	// 2024-01-01 is a Monday
+	{Schedule{Period: "weekly"}, date(2024, 1, 1, 0), date(2024, 1, 8, 0)},
+	{Schedule{Period: "weekly"}, date(2024, 1, 1, 10), date(2024, 1, 8, 0)},
+	{Schedule{Period: "weekly"}, date(2024, 1, 7, 23), date(2024, 1, 8, 0)},
+	{Schedule{Period: "monthly"}, date(2024, 1, 31, 15), date(2024, 2, 1, 0)},
+	{Schedule{Period: "monthly"}, date(2024, 12, 1, 0), date(2025, 1, 1, 0)},
+}
+
+func TestScheduleNext(t *testing.T) {
+	for _, test := range nextTests {
+		if got := test.s.Next(test.t); !got.Equal(test.want) {
+			t.Errorf("%+v from %v: got %v, want %v", test.s, test.t,
+				got, test.want)
+		}
+	}
+}
+
+func TestScheduleNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("can't load time zone:", err)
+	}
+	// clocks go forward on 2024-03-10 at 2:00
+	from := time.Date(2024, 3, 10, 0, 0, 0, 0, loc)
+	next := Schedule{Period: "daily"}.Next(from)
+	if want := time.Date(2024, 3, 11, 0, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("got %v, want %v", next, want)
+	}
+	if d := next.Sub(from); d != 23*time.Hour {
+		t.Errorf("got a day of %v, want 23h", d)
+	}
+}
+
+var parseDurationTests = []struct {
+	in  string
+	out time.Duration
+	ok  bool
+}{
+	{"90m", 90 * time.Minute, true},
+	{"2d", 48 * time.Hour, true},
+	{"1.5d", 36 * time.Hour, true},
+	{"1w", 7 * 24 * time.Hour, true},
+	{"1w12h", 180 * time.Hour, true},
+	{"d", 0, false},
+	{"1x", 0, false},
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, test := range parseDurationTests {
+		d, err := parseDuration(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v, want ok = %v", test.in, err, test.ok)
+		} else if d != test.out {
+			t.Errorf("%q: got %v, want %v", test.in, d, test.out)
+		}
+	}
+}
+
+var scheduleTests = []struct {
+	in  string
+	out Schedule
+	ok  bool
+}{
+	{"daily", Schedule{Period: "daily"}, true},
+	{"Weekly", Schedule{Period: "weekly"}, true},
+	{"MONTHLY", Schedule{Period: "monthly"}, true},
+	{"90d", Schedule{Interval: 90 * 24 * time.Hour}, true},
+	{"0s", Schedule{}, false},
+	{"-1h", Schedule{}, false},
+	{"yearly", Schedule{}, false},
+}
+
+func TestScheduleValue(t *testing.T) {
+	for _, test := range scheduleTests {
+		var v ScheduleValue
+		err := v.Set(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v, want ok = %v", test.in, err, test.ok)
+		} else if err != nil {
+			if !strings.Contains(err.Error(), "daily, weekly, monthly") {
+				t.Errorf("%q: got %v, want error listing schedules",
+					test.in, err)
+			}
+		} else if Schedule(v) != test.out {
+			t.Errorf("%q: got %+v, want %+v", test.in, v, test.out)
+		}
+	}
+}
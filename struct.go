@@ -0,0 +1,115 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	errNotStruct   = errors.New("not a pointer to struct")
+	errUnsupported = errors.New("unsupported field type")
+	errBadTag      = errors.New("invalid tag option")
+)
+
+// fieldValue returns a Value setting the variable pointed to by p,
+// and its Kind.
+func fieldValue(p interface{}) (Value, int) {
+	switch p := p.(type) {
+	case *string:
+		return (*StringValue)(p), HasArg
+	case *bool:
+		return (*BoolValue)(p), NoArg
+	case *int64:
+		return (*Int64Value)(p), HasArg
+	case *int:
+		return (*IntValue)(p), HasArg
+	case *uint64:
+		return (*Uint64Value)(p), HasArg
+	case *time.Duration:
+		return (*DurationValue)(p), HasArg
+	case *time.Time:
+		return NewTimeValue(p), HasArg
+	case **time.Location:
+		return NewTimezoneValue(p), HasArg
+	case **regexp.Regexp:
+		return NewRegexpValue(p), HasArg
+	case *net.IP:
+		return (*IPValue)(p), HasArg
+	case *[]string:
+		return (*StringSliceValue)(p), HasArg
+	case *[]int:
+		return NewIntSliceValue(p), HasArg
+	case *map[string]string:
+		return NewMapValue(p), HasArg
+	case Value:
+		return p, HasArg
+	case encoding.TextUnmarshaler:
+		return TextValue(p), HasArg
+	}
+	return nil, 0
+}
+
+// StructVars returns Vars for the fields of the struct pointed to by
+// ptr, in order, setting the fields.  Only exported fields tagged
+// with the variable name are included:
+//
+//	type Config struct {
+//		Addr    string        `conf:"addr,required"`
+//		Timeout time.Duration `conf:"timeout"`
+//		Verbose bool          `conf:"verbose"`
+//	}
+//
+// The name may be followed by comma separated options; the only one
+// is "required", setting Required.  The Value is chosen by field type:
+// StringValue for string, BoolValue (with Kind NoArg) for bool,
+// Int64Value, IntValue (int), Uint64Value, DurationValue, TimeValue,
+// TimezoneValue (*time.Location), RegexpValue (*regexp.Regexp),
+// IPValue, StringSliceValue, IntSliceValue ([]int) and MapValue
+// (map[string]string).  Fields of other types whose pointers
+// implement Value or encoding.TextUnmarshaler are set by these
+// methods.  Fields of any other type, including other numeric types,
+// like int32, uint or float64, are an error.
+//
+// The returned Vars have no Flag; it may be set by the caller.
+func StructVars(ptr interface{}) ([]Var, error) {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, errNotStruct
+	}
+	rv = rv.Elem()
+	t := rv.Type()
+	var vars []Var
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("conf")
+		if tag == "" || f.PkgPath != "" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		v := Var{Name: opts[0]}
+		for _, o := range opts[1:] {
+			if o != "required" {
+				return nil, fmt.Errorf("%s: %w: %s", f.Name, errBadTag, o)
+			}
+			v.Required = true
+		}
+		if v.Val, v.Kind = fieldValue(rv.Field(i).Addr().Interface()); v.Val == nil {
+			return nil, fmt.Errorf("%s: %w: %s", f.Name, errUnsupported, f.Type)
+		}
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
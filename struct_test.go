@@ -0,0 +1,109 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type structConfig struct {
+	Str      string            `conf:"str,required"`
+	Bool     bool              `conf:"bool"`
+	Int      int64             `conf:"int"`
+	Plain    int               `conf:"plain"`
+	Uint     uint64            `conf:"uint"`
+	Duration time.Duration     `conf:"duration"`
+	Time     time.Time         `conf:"time"`
+	Zone     *time.Location    `conf:"zone"`
+	Regexp   *regexp.Regexp    `conf:"regexp"`
+	IP       net.IP            `conf:"ip"`
+	Strings  []string          `conf:"strings"`
+	Ints     []int             `conf:"ints"`
+	Map      map[string]string `conf:"map"`
+	Count    CountValue        `conf:"count"`
+	Big      big.Int           `conf:"big"`
+	Untagged string
+	unexp    string `conf:"unexp"`
+}
+
+const structIn = `str = s
+bool = true
+int = -1
+plain = 0x10
+uint = 1
+duration = 1s
+time = 2024-01-02T15:04:05Z
+zone = UTC
+regexp = "^a$"
+ip = 10.0.0.1
+strings = a
+strings = b
+ints = "1,2"
+map = "k=v"
+count = 3
+big = 123456789012345678901234567890
+`
+
+func TestStructVars(t *testing.T) {
+	var c structConfig
+	vars, err := StructVars(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) != 15 {
+		t.Fatalf("got %d Vars, want 15", len(vars))
+	}
+	if !vars[0].Required || vars[1].Kind != NoArg || vars[2].Kind != HasArg {
+		t.Errorf("got Required %v, Kinds %d, %d", vars[0].Required,
+			vars[1].Kind, vars[2].Kind)
+	}
+	if err := ParseString(structIn, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	switch {
+	case c.Str != "s", !c.Bool, c.Int != -1, c.Plain != 16, c.Uint != 1,
+		c.Duration != time.Second,
+		!c.Time.Equal(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)),
+		c.Zone != time.UTC, c.Regexp == nil || c.Regexp.String() != "^a$",
+		!c.IP.Equal(net.IPv4(10, 0, 0, 1)),
+		!reflect.DeepEqual(c.Strings, []string{"a", "b"}),
+		!reflect.DeepEqual(c.Ints, []int{1, 2}),
+		!reflect.DeepEqual(c.Map, map[string]string{"k": "v"}),
+		c.Count != 3, c.Big.Cmp(n) != 0:
+		t.Errorf("got %+v", c)
+	}
+	if c.Untagged != "" || c.unexp != "" {
+		t.Error("untagged or unexported field set")
+	}
+}
+
+func TestStructVarsErrors(t *testing.T) {
+	var bad struct {
+		F float64 `conf:"f"`
+	}
+	if _, err := StructVars(&bad); !errors.Is(err, errUnsupported) {
+		t.Errorf("unsupported: got %v", err)
+	}
+	var opt struct {
+		S string `conf:"s,optional"`
+	}
+	if _, err := StructVars(&opt); !errors.Is(err, errBadTag) {
+		t.Errorf("bad tag: got %v", err)
+	}
+	var c structConfig
+	if _, err := StructVars(c); !errors.Is(err, errNotStruct) {
+		t.Errorf("non-pointer: got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+// suggestError is an error with a suggested correction.
+type suggestError struct {
+	err  error  // original error
+	name string // suggested name
+}
+
+func (e *suggestError) Error() string { return e.err.Error() + e.hint() }
+
+// hint returns the suggestion as appended to error messages.
+func (e *suggestError) hint() string { return "; did you mean '" + e.name + "'?" }
+
+func (e *suggestError) Unwrap() error { return e.err }
+
+// withSuggestion returns err with the suggestion s attached,
+// or err if s is empty.
+func withSuggestion(err error, s string) error {
+	if s == "" {
+		return err
+	}
+	return &suggestError{err, s}
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range s {
+		cur[0] = i + 1
+		for j := range t {
+			d := prev[j]
+			if s[i] != t[j] {
+				d++
+			}
+			if prev[j+1]+1 < d {
+				d = prev[j+1] + 1
+			}
+			if cur[j]+1 < d {
+				d = cur[j] + 1
+			}
+			cur[j+1] = d
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(t)]
+}
+
+// suggest returns the name in names closest to name, or "" if none
+// is close enough to be a plausible typo.
+func suggest(name string, names []string) string {
+	best, limit := "", len([]rune(name))/3+1
+	for _, n := range names {
+		if n == "" {
+			continue
+		}
+		if d := editDistance(name, n); d <= limit {
+			best, limit = n, d-1
+		}
+	}
+	return best
+}
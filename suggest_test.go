@@ -0,0 +1,71 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"testing"
+)
+
+var editDistanceTests = []struct {
+	a, b string
+	d    int
+}{
+	{"", "", 0},
+	{"abc", "", 3},
+	{"verbose", "verbose", 0},
+	{"verbsoe", "verbose", 2},
+	{"colour", "color", 1},
+	{"héllo", "hello", 1},
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, test := range editDistanceTests {
+		if d := editDistance(test.a, test.b); d != test.d {
+			t.Errorf("%q, %q: got %d, want %d", test.a, test.b, d, test.d)
+		}
+	}
+}
+
+var suggestTests = []struct {
+	name, want string
+}{
+	{"verbsoe", "verbose"},
+	{"colr", "color"},
+	{"color", "color"},
+	{"vrb", ""}, // 4 edits from "verbose", limit 2
+	{"xyz", ""},
+	{"", ""},
+}
+
+func TestSuggest(t *testing.T) {
+	names := []string{"", "verbose", "color", "colors"}
+	for _, test := range suggestTests {
+		if s := suggest(test.name, names); s != test.want {
+			t.Errorf("%q: got %q, want %q", test.name, s, test.want)
+		}
+	}
+}
+
+func TestUnknownVarSuggestion(t *testing.T) {
+	vars := []Var{{Name: "verbose", Val: new(StringValue)}}
+	err := ParseString("verbsoe = x\n", "a.conf", vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrUnknownVar) {
+		t.Fatalf("got %v, want ParseError wrapping ErrUnknownVar", err)
+	}
+	want := "a.conf:1:1: verbsoe: unknown variable; did you mean 'verbose'?\n"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+	err = ParseString("xyz = x\n", "a.conf", vars)
+	if want := "a.conf:1:1: xyz: unknown variable\n"; err == nil ||
+		err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
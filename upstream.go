@@ -0,0 +1,84 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Upstream is a URL with a weight.
+type Upstream struct {
+	URL    *url.URL
+	Weight int
+}
+
+// Upstreams is a list of weighted URLs.
+type Upstreams []Upstream
+
+// Pick returns a random URL from the list, each URL being picked
+// with probability proportional to its weight, or nil if the list
+// is empty or the weights don't add up to a positive number.
+func (l Upstreams) Pick() *url.URL {
+	total := 0
+	for _, u := range l {
+		total += u.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+	n := rand.Intn(total)
+	for _, u := range l {
+		if n -= u.Weight; n < 0 {
+			return u.URL
+		}
+	}
+	panic("unreachable")
+}
+
+// UpstreamsValue represents a configuration variable's Upstreams value.
+// Syntax: comma separated list of absolute URLs, each optionally
+// followed by '|' and a positive weight (1 if omitted), e.g.:
+// "http://a|3,http://b|1".
+type UpstreamsValue Upstreams
+
+func (v *UpstreamsValue) Set(s string) error {
+	var l UpstreamsValue
+	for _, e := range strings.Split(s, ",") {
+		e = strings.TrimSpace(e)
+		u := Upstream{Weight: 1}
+		if i := strings.LastIndex(e, "|"); i != -1 {
+			w, err := strconv.Atoi(e[i+1:])
+			if err != nil || w <= 0 {
+				return fmt.Errorf("upstream %s: invalid weight", e)
+			}
+			u.Weight, e = w, e[:i]
+		}
+		var err error
+		if u.URL, err = url.Parse(e); err != nil {
+			return fmt.Errorf("upstream %s: %v", e, err)
+		}
+		if !u.URL.IsAbs() || u.URL.Host == "" {
+			return fmt.Errorf("upstream %s: invalid URL", e)
+		}
+		l = append(l, u)
+	}
+	*v = l
+	return nil
+}
+
+func (v *UpstreamsValue) String() string {
+	s := make([]string, len(*v))
+	for i, u := range *v {
+		s[i] = u.URL.String() + "|" + strconv.Itoa(u.Weight)
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,63 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"net/url"
+	"testing"
+)
+
+var upstreamsTests = []struct {
+	in  string
+	out string // String of the result, or "" if Set fails
+}{
+	{"http://a", "http://a|1"},
+	{"http://a|3, https://b:8080/x|1", "http://a|3,https://b:8080/x|1"},
+	{"http://a|0", ""},
+	{"http://a|-1", ""},
+	{"http://a|x", ""},
+	{"a.example.com", ""},
+	{"http://a,", ""},
+}
+
+func TestUpstreamsValue(t *testing.T) {
+	for _, test := range upstreamsTests {
+		var v UpstreamsValue
+		err := v.Set(test.in)
+		if (err == nil) != (test.out != "") {
+			t.Errorf("%q: got error %v", test.in, err)
+		} else if err == nil && v.String() != test.out {
+			t.Errorf("%q: got %q, want %q", test.in, v.String(), test.out)
+		}
+	}
+}
+
+func TestPick(t *testing.T) {
+	a, _ := url.Parse("http://a")
+	b, _ := url.Parse("http://b")
+	if u := (Upstreams{}).Pick(); u != nil {
+		t.Errorf("empty list: got %v, want nil", u)
+	}
+	if u := (Upstreams{{a, -5}, {b, 3}}).Pick(); u != nil {
+		t.Errorf("negative total: got %v, want nil", u)
+	}
+	l := Upstreams{{a, 0}, {b, 1}}
+	for i := 0; i < 100; i++ {
+		if u := l.Pick(); u != b {
+			t.Fatalf("got %v, want only %v", u, b)
+		}
+	}
+	seen := make(map[*url.URL]bool)
+	l = Upstreams{{a, 1}, {b, 1}}
+	for i := 0; i < 1000 && len(seen) < 2; i++ {
+		seen[l.Pick()] = true
+	}
+	if !seen[a] || !seen[b] {
+		t.Errorf("got %v, want both URLs picked", seen)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// flagSyntax returns the flag and argument part of a usage line.
+func flagSyntax(v *Var) string {
+	var short, long string
+	switch v.Kind {
+	case HasArg:
+		short, long = " ARG", "=ARG"
+	case OptionalArg:
+		short, long = "[ARG]", "[=ARG]"
+	case LineArg:
+		short, long = " ...", " ..."
+	}
+	switch {
+	case v.Flag != 0 && v.Name != "":
+		return "-" + string(v.Flag) + ", --" + v.Name + long
+	case v.Flag != 0:
+		return "-" + string(v.Flag) + short
+	}
+	return "    --" + v.Name + long
+}
+
+/*
+Usage writes a help message describing vars to w, one line per Var,
+in GetOptLong syntax.  Each line shows the short flag, the long
+name, the argument if the Var takes one, and the Help text, followed
+by the Default, if any, and "(required)" for Vars required to be set
+in the configuration file.  Columns are aligned.  Hidden Vars are
+skipped.  For example:
+
+	-c ARG             configuration file
+	-n, --number=ARG   number of things (required)
+	    --color[=ARG]  colorize output
+*/
+func Usage(w io.Writer, vars []Var) {
+	syntax := make([]string, len(vars))
+	width := 0
+	for i := range vars {
+		if vars[i].Hidden {
+			continue
+		}
+		syntax[i] = flagSyntax(&vars[i])
+		if n := utf8.RuneCountInString(syntax[i]); n > width {
+			width = n
+		}
+	}
+	for i := range vars {
+		v := &vars[i]
+		if v.Hidden {
+			continue
+		}
+		help := v.Help
+		if v.Default != "" {
+			help += " (default: " + v.Default + ")"
+		}
+		if v.Required {
+			help += " (required)"
+		}
+		help = strings.TrimLeft(help, " ")
+		line := "  " + syntax[i]
+		if help != "" {
+			line += strings.Repeat(" ",
+				width+2-utf8.RuneCountInString(syntax[i])) + help
+		}
+		io.WriteString(w, line+"\n")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+const usageGolden = `  -c ARG             configuration file
+  -n, --number=ARG   number of things (default: 3) (required)
+      --color[=ARG]  colorize output
+  -v, --verbose
+  -x ...             run the rest
+      --quiet        be quiet
+`
+
+func TestUsage(t *testing.T) {
+	var (
+		s string
+		b bool
+	)
+	vars := []Var{
+		{Flag: 'c', Val: (*StringValue)(&s), Help: "configuration file"},
+		{Flag: 'n', Name: "number", Val: (*StringValue)(&s),
+			Help: "number of things", Default: "3", Required: true},
+		{Name: "color", Kind: OptionalArg, Val: (*StringValue)(&s),
+			Help: "colorize output"},
+		{Flag: 'v', Name: "verbose", Kind: NoArg, Val: (*BoolValue)(&b)},
+		{Flag: 'x', Kind: LineArg, Val: (*StringValue)(&s),
+			Help: "run the rest"},
+		{Name: "quiet", Kind: NoArg, Val: (*BoolValue)(&b),
+			Help: "be quiet"},
+	}
+	var w strings.Builder
+	Usage(&w, vars)
+	if got := w.String(); got != usageGolden {
+		t.Errorf("got:\n%s\nwant:\n%s", got, usageGolden)
+	}
+}
+
+func TestUsageHidden(t *testing.T) {
+	var s, secret string
+	vars := []Var{
+		{Flag: 'c', Val: (*StringValue)(&s), Help: "configuration file"},
+		{Flag: 'D', Name: "debug-secret", Val: (*StringValue)(&secret),
+			Hidden: true, Help: "internal"},
+	}
+	var w strings.Builder
+	Usage(&w, vars)
+	if want := "  -c ARG  configuration file\n"; w.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", w.String(), want)
+	}
+	argv := []string{"-D", "x", "--debug-secret", "y"}
+	_, err := defaultOptions.getOpt(argv[:2], vars, gnuLong)
+	if err != nil || secret != "x" {
+		t.Errorf("GetOpt: got %q, %v, want \"x\"", secret, err)
+	}
+	Reset(vars)
+	_, err = defaultOptions.getOpt(argv[2:], vars, gnuLong)
+	if err != nil || secret != "y" {
+		t.Errorf("GetOpt: got %q, %v, want \"y\"", secret, err)
+	}
+	Reset(vars)
+	if err := ParseString("debug-secret = z\n", "", vars); err != nil ||
+		secret != "z" {
+		t.Errorf("Parse: got %q, %v, want \"z\"", secret, err)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+var (
+	errDupFlag = errors.New("duplicate flag")
+	errDupName = errors.New("duplicate name")
+	errNilVal  = errors.New("nil Val")
+	errBadKind = errors.New("invalid Kind")
+)
+
+// label returns a description of vars[i] for error messages.
+func label(vars []Var, i int) string {
+	switch v := &vars[i]; {
+	case v.Name != "":
+		return v.Name
+	case v.Flag != 0:
+		return "-" + string(v.Flag)
+	}
+	return "vars[" + strconv.Itoa(i) + "]"
+}
+
+// Validate checks vars for programming errors that parsing functions
+// don't detect or detect late: Vars with the same Flag or Name,
+// including AliasFlags and Aliases, of which only the first would
+// ever be set; Vars with nil Val or with invalid Kind; and Required
+// Vars with Default.  It returns an error describing the first
+// problem found, or nil.
+//
+// Parse and GetOpt don't call Validate; it's meant to be called
+// once during development or testing.
+func Validate(vars []Var) error {
+	flags := make(map[rune]bool)
+	names := make(map[string]bool)
+	for i := range vars {
+		v := &vars[i]
+		var err error
+		for _, f := range v.flags() {
+			if f != 0 && flags[f] {
+				err = errDupFlag
+			}
+			flags[f] = true
+		}
+		for _, name := range v.names() {
+			if name != "" && names[name] {
+				err = errDupName
+			}
+			names[name] = true
+		}
+		switch {
+		case err != nil:
+		case v.Val == nil:
+			err = errNilVal
+		case v.Kind < HasArg || v.Kind > OptionalArg:
+			err = errBadKind
+		case v.Required && v.Default != "":
+			err = ErrReqDefault
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", label(vars, i), err)
+		}
+	}
+	return nil
+}
+
+// cloner is implemented by Values of this package that set variables
+// through pointers.  clone returns a Value of the same kind setting
+// a new variable, or nil if it can't.
+type cloner interface {
+	clone() Value
+}
+
+// plainType returns the type of the variable v points to if v is
+// one of the Values of this package that are variables of their own,
+// like *StringValue, or nil otherwise.
+func plainType(v Value) reflect.Type {
+	switch v.(type) {
+	case *StringValue, *BoolValue, *Int64Value, *IntValue,
+		*DecimalInt64Value, *Uint64Value, *CountValue, *StringSliceValue, *DurationValue,
+		*ScheduleValue, *UpstreamsValue, *EmailListValue, *UserValue,
+		*GroupValue, *TemporaryBoolValue, *IPValue, *IPNetValue,
+		*ByteSizeValue:
+		return reflect.TypeOf(v).Elem()
+	}
+	return nil
+}
+
+// cloneValue returns a Value like v setting a new variable, or nil
+// if v is not a Value of this package.  Values of other packages may
+// rely on setup that a new zero value lacks, so they're not cloned.
+func cloneValue(v Value) Value {
+	if c, ok := v.(cloner); ok {
+		return c.clone()
+	}
+	t := plainType(v)
+	if t == nil {
+		return nil
+	}
+	return reflect.New(t).Interface().(Value)
+}
+
+// zeroValue sets the variable v points to to its zero value, if it
+// can tell how: Resetters are Reset, the presence recorded by
+// OptionalValue is cleared, and the plain Values of this package,
+// like StringValue, are set to zero.  Other Values are left alone.
+func zeroValue(v Value) {
+	if o, ok := v.(*OptionalValue); ok {
+		zeroValue(o.Value)
+		o.set = false
+		return
+	}
+	if r, ok := v.(Resetter); ok {
+		r.Reset()
+		return
+	}
+	if t := plainType(v); t != nil {
+		reflect.ValueOf(v).Elem().Set(reflect.Zero(t))
+	}
+}
+
+// Check parses the configuration file from r like Parse, reporting
+// the same errors, but without setting the variables pointed to by
+// vars or recording them as set.  Values of this package are checked
+// by calling Set on new Values of the same kind, so invalid values,
+// like a bad number for Int64Value or a bad choice for EnumValue, get
+// reported.  Other Values, including those defined by the program and
+// those returned by FuncValue, aren't checked, as Check can't tell how
+// to create new copies of them.  The Check functions of vars are not
+// called, as they may validate the variables rather than their
+// argument.  Neither are the OnSet, Unknown and Warn functions of
+// Options, though settings of unknown variables are ignored if Unknown
+// is set.  Include directives are not supported, like in Parse.
+//
+// The record of vars having been set is ignored, so Check can be used
+// after parsing the command line or a previous file, e.g., to validate
+// a new configuration file before applying it with Reload.
+func Check(r io.Reader, filename string, vars []Var) error {
+	return defaultOptions.Check(r, filename, vars)
+}
+
+// Check is like the package-level Check, but modified by o.
+func (o *Options) Check(r io.Reader, filename string, vars []Var) error {
+	nop := FuncValue(func(string) error { return nil })
+	c := make([]Var, len(vars))
+	copy(c, vars)
+	for i := range c {
+		if c[i].Val = cloneValue(vars[i].Val); c[i].Val == nil {
+			c[i].Val = nop
+		}
+		// Check would look at the real variables
+		c[i].Check = nil
+		c[i].set, c[i].flagSet = false, false
+	}
+	opt := *o
+	opt.OnSet, opt.Warn = nil, nil
+	if opt.Unknown != nil {
+		opt.Unknown = func(string, string) error { return nil }
+	}
+	return newParser(r, filename, c, &opt).run()
+}
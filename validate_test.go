@@ -0,0 +1,158 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var validateTests = []struct {
+	vars []Var
+	err  error
+}{
+	{[]Var{
+		{Flag: 'a', Name: "a", Val: new(StringValue)},
+		{Flag: 'b', Name: "b", Val: new(StringValue), Required: true},
+		{Name: "c", Val: new(StringValue), Default: "c"},
+	}, nil},
+	{[]Var{
+		{Flag: 'a', Val: new(StringValue)},
+		{Flag: 'a', Val: new(StringValue)},
+	}, errDupFlag},
+	{[]Var{
+		{Flag: 'a', Val: new(StringValue)},
+		{Flag: 'b', AliasFlags: []rune{'a'}, Val: new(StringValue)},
+	}, errDupFlag},
+	{[]Var{
+		{Name: "a", Val: new(StringValue)},
+		{Name: "a", Val: new(StringValue)},
+	}, errDupName},
+	{[]Var{
+		{Name: "a", Aliases: []string{"b"}, Val: new(StringValue)},
+		{Name: "b", Val: new(StringValue)},
+	}, errDupName},
+	{[]Var{{Name: "a"}}, errNilVal},
+	{[]Var{{Name: "a", Val: new(StringValue), Kind: -1}}, errBadKind},
+	{[]Var{{Name: "a", Val: new(StringValue), Kind: 42}}, errBadKind},
+	{[]Var{
+		{Name: "a", Val: new(StringValue), Required: true, Default: "x"},
+	}, ErrReqDefault},
+}
+
+func TestValidate(t *testing.T) {
+	for i, test := range validateTests {
+		err := Validate(test.vars)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("#%d: got %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var n int64
+	vars := []Var{{Name: "n", Val: (*Int64Value)(&n)}}
+	if err := Check(strings.NewReader("n = 5\n"), "", vars); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if n != 0 || WasSet(&vars[0]) {
+		t.Errorf("Check set n: n = %d, WasSet = %v", n, WasSet(&vars[0]))
+	}
+	err := Check(strings.NewReader("n = x\n"), "", vars)
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Check of invalid value: got %v, want strconv.ErrSyntax", err)
+	}
+}
+
+// labels is a user Value that needs its map allocated.
+type labels map[string]string
+
+func (l *labels) Set(s string) error {
+	(*l)[s] = s
+	return nil
+}
+
+// choice is a user Value that keeps its allowed choices.
+type choice struct {
+	val     string
+	choices []string
+}
+
+func (c *choice) Set(s string) error {
+	for _, x := range c.choices {
+		if s == x {
+			c.val = s
+			return nil
+		}
+	}
+	return fmt.Errorf("bad choice %q", s)
+}
+
+func TestCheckUserValues(t *testing.T) {
+	l := labels{}
+	c := &choice{choices: []string{"a", "b"}}
+	vars := []Var{{Name: "l", Val: &l}, {Name: "mode", Val: c}}
+	err := Check(strings.NewReader("l = x\nmode = a\n"), "", vars)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(l) != 0 || c.val != "" {
+		t.Errorf("Check set variables: l = %v, mode = %q", l, c.val)
+	}
+}
+
+func TestCheckAfterParse(t *testing.T) {
+	var n int64
+	vars := []Var{{Name: "n", Val: (*Int64Value)(&n)}}
+	if err := ParseString("n = 1\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(strings.NewReader("n = 2\n"), "", vars); err != nil {
+		t.Errorf("Check after Parse: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("n = %d, want 1", n)
+	}
+}
+
+func TestCheckAfterGetOpt(t *testing.T) {
+	var n int64
+	vars := []Var{{Flag: 'n', Name: "n", Val: (*Int64Value)(&n)}}
+	if _, err := defaultOptions.getOpt([]string{"-n", "1"}, vars,
+		short); err != nil {
+		t.Fatal(err)
+	}
+	err := Check(strings.NewReader("n = notanumber\n"), "", vars)
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Check after GetOpt: got %v, want strconv.ErrSyntax", err)
+	}
+}
+
+func TestCheckHooks(t *testing.T) {
+	var s string
+	called := false
+	hook := func(string, string) error { called = true; return nil }
+	o := &Options{
+		Unknown: hook,
+		OnSet: func(string, string, int) error {
+			called = true
+			return nil
+		},
+	}
+	vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+	err := o.Check(strings.NewReader("s = a\nother = b\n"), "", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called {
+		t.Error("Check called OnSet or Unknown")
+	}
+}
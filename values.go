@@ -0,0 +1,983 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/mail"
+	"os/user"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnumValue represents a configuration variable's string value
+// restricted to a fixed set of choices.
+type EnumValue struct {
+	target  *string
+	choices []string
+	fold    bool
+}
+
+// NewEnumValue returns an EnumValue setting target to one of choices.
+// Matching is case sensitive.
+func NewEnumValue(target *string, choices ...string) *EnumValue {
+	return &EnumValue{target, choices, false}
+}
+
+// NewEnumValueFold is like NewEnumValue, but matching is case
+// insensitive.  The target is set to the choice as spelled in choices.
+func NewEnumValueFold(target *string, choices ...string) *EnumValue {
+	return &EnumValue{target, choices, true}
+}
+
+func (v *EnumValue) Set(s string) error {
+	for _, c := range v.choices {
+		if s == c || v.fold && strings.EqualFold(s, c) {
+			*v.target = c
+			return nil
+		}
+	}
+	return errors.New("invalid value, must be one of: " +
+		strings.Join(v.choices, ", "))
+}
+
+func (v *EnumValue) String() string { return *v.target }
+
+func (v *EnumValue) Reset() { *v.target = "" }
+
+func (v *EnumValue) clone() Value {
+	return &EnumValue{new(string), v.choices, v.fold}
+}
+
+// LevelValue represents a configuration variable's integer value
+// given as one of a set of words, like log levels.
+type LevelValue struct {
+	target *int
+	levels map[string]int
+	names  []string // sorted by level
+}
+
+// NewLevelValue returns a LevelValue setting target to the level
+// named by one of the keys of levels, e.g.:
+//
+//	NewLevelValue(&level, map[string]int{
+//		"debug": 0, "info": 1, "warn": 2, "error": 3,
+//	})
+//
+// Matching is case insensitive.
+func NewLevelValue(target *int, levels map[string]int) *LevelValue {
+	v := &LevelValue{target, make(map[string]int, len(levels)), nil}
+	for name, n := range levels {
+		v.levels[strings.ToLower(name)] = n
+		v.names = append(v.names, name)
+	}
+	sort.Slice(v.names, func(i, j int) bool {
+		a, b := levels[v.names[i]], levels[v.names[j]]
+		return a < b || a == b && v.names[i] < v.names[j]
+	})
+	return v
+}
+
+func (v *LevelValue) Set(s string) error {
+	n, ok := v.levels[strings.ToLower(s)]
+	if !ok {
+		return errors.New("invalid level, must be one of: " +
+			strings.Join(v.names, ", "))
+	}
+	*v.target = n
+	return nil
+}
+
+// String returns the name of the level, or the number if it has none.
+func (v *LevelValue) String() string {
+	for _, name := range v.names {
+		if v.levels[strings.ToLower(name)] == *v.target {
+			return name
+		}
+	}
+	return strconv.Itoa(*v.target)
+}
+
+func (v *LevelValue) Reset() { *v.target = 0 }
+
+func (v *LevelValue) clone() Value {
+	return &LevelValue{new(int), v.levels, v.names}
+}
+
+// IntRangeValue represents a configuration variable's int64 value
+// restricted to a range, like a port number.  Numbers are parsed
+// like in Int64Value.
+type IntRangeValue struct {
+	target   *int64
+	min, max int64
+}
+
+// NewIntRangeValue returns an IntRangeValue setting target to
+// a number from min to max, inclusive.
+func NewIntRangeValue(target *int64, min, max int64) *IntRangeValue {
+	return &IntRangeValue{target, min, max}
+}
+
+func (v *IntRangeValue) Set(s string) error {
+	var n Int64Value
+	if err := n.Set(s); err != nil {
+		return err
+	}
+	if int64(n) < v.min || int64(n) > v.max {
+		return fmt.Errorf("%w, must be from %d to %d",
+			strconv.ErrRange, v.min, v.max)
+	}
+	*v.target = int64(n)
+	return nil
+}
+
+func (v *IntRangeValue) String() string { return strconv.FormatInt(*v.target, 10) }
+
+func (v *IntRangeValue) Reset() { *v.target = 0 }
+
+func (v *IntRangeValue) clone() Value {
+	return &IntRangeValue{new(int64), v.min, v.max}
+}
+
+// UintRangeValue is like IntRangeValue, but for uint64 values,
+// parsed like in Uint64Value.
+type UintRangeValue struct {
+	target   *uint64
+	min, max uint64
+}
+
+// NewUintRangeValue returns a UintRangeValue setting target to
+// a number from min to max, inclusive.
+func NewUintRangeValue(target *uint64, min, max uint64) *UintRangeValue {
+	return &UintRangeValue{target, min, max}
+}
+
+func (v *UintRangeValue) Set(s string) error {
+	var n Uint64Value
+	if err := n.Set(s); err != nil {
+		return err
+	}
+	if uint64(n) < v.min || uint64(n) > v.max {
+		return fmt.Errorf("%w, must be from %d to %d",
+			strconv.ErrRange, v.min, v.max)
+	}
+	*v.target = uint64(n)
+	return nil
+}
+
+func (v *UintRangeValue) String() string { return strconv.FormatUint(*v.target, 10) }
+
+func (v *UintRangeValue) Reset() { *v.target = 0 }
+
+func (v *UintRangeValue) clone() Value {
+	return &UintRangeValue{new(uint64), v.min, v.max}
+}
+
+// EmailListValue represents a configuration variable's value
+// as a comma separated list of email addresses, such as
+// "a@example.com, Bob <b@example.com>".  Every address is
+// validated with net/mail.  Display names containing commas
+// are not supported.
+type EmailListValue []*mail.Address
+
+func (v *EmailListValue) Set(s string) error {
+	var l []*mail.Address
+	for i, a := range strings.Split(s, ",") {
+		a = strings.TrimSpace(a)
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return fmt.Errorf("address %d (%s): %v", i+1, a, err)
+		}
+		l = append(l, addr)
+	}
+	*v = l
+	return nil
+}
+
+func (v *EmailListValue) String() string {
+	s := make([]string, len(*v))
+	for i, a := range *v {
+		s[i] = a.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// UserValue represents a configuration variable's user ID value.
+// The user may be given by name or by numeric ID, and must exist.
+type UserValue int
+
+func (v *UserValue) Set(s string) error {
+	var (
+		u   *user.User
+		err error
+	)
+	if _, e := strconv.Atoi(s); e == nil {
+		u, err = user.LookupId(s)
+	} else {
+		u, err = user.Lookup(s)
+	}
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return err
+	}
+	*v = UserValue(id)
+	return nil
+}
+
+func (v *UserValue) String() string { return strconv.Itoa(int(*v)) }
+
+// GroupValue represents a configuration variable's group ID value.
+// The group may be given by name or by numeric ID, and must exist.
+type GroupValue int
+
+func (v *GroupValue) Set(s string) error {
+	var (
+		g   *user.Group
+		err error
+	)
+	if _, e := strconv.Atoi(s); e == nil {
+		g, err = user.LookupGroupId(s)
+	} else {
+		g, err = user.LookupGroup(s)
+	}
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return err
+	}
+	*v = GroupValue(id)
+	return nil
+}
+
+func (v *GroupValue) String() string { return strconv.Itoa(int(*v)) }
+
+// TimezoneValue represents a configuration variable's time zone value,
+// loaded with time.LoadLocation.  "UTC" and "Local" are accepted,
+// as well as names in the IANA Time Zone database, like
+// "America/New_York".
+type TimezoneValue struct {
+	target **time.Location
+}
+
+// NewTimezoneValue returns a TimezoneValue setting target.
+func NewTimezoneValue(target **time.Location) *TimezoneValue {
+	return &TimezoneValue{target}
+}
+
+func (v *TimezoneValue) Set(s string) error {
+	if s == "" {
+		// time.LoadLocation returns UTC for ""
+		return ErrSyntax
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return err
+	}
+	*v.target = loc
+	return nil
+}
+
+func (v *TimezoneValue) String() string {
+	if *v.target == nil {
+		return ""
+	}
+	return (*v.target).String()
+}
+
+func (v *TimezoneValue) Reset() { *v.target = nil }
+
+func (v *TimezoneValue) clone() Value {
+	return &TimezoneValue{new(*time.Location)}
+}
+
+// TemporaryBool is a boolean flag that expires at a given time.
+type TemporaryBool struct {
+	Value bool
+	Until time.Time // expiry time; zero if the flag never expires
+}
+
+// Active reports whether the flag is on at time now.
+func (b TemporaryBool) Active(now time.Time) bool {
+	return b.Value && (b.Until.IsZero() || now.Before(b.Until))
+}
+
+// TemporaryBoolValue represents a configuration variable's
+// TemporaryBool value.  Syntax: a boolean as for BoolValue,
+// optionally followed by "until" and an RFC 3339 timestamp,
+// separated by whitespace (so the value must be quoted), e.g.:
+// "on until 2024-12-31T00:00:00Z".
+type TemporaryBoolValue TemporaryBool
+
+func (v *TemporaryBoolValue) Set(s string) error {
+	var b TemporaryBool
+	f := strings.Fields(s)
+	switch {
+	case len(f) == 3 && strings.EqualFold(f[1], "until"):
+		t, err := time.Parse(time.RFC3339, f[2])
+		if err != nil {
+			return err
+		}
+		b.Until = t
+	case len(f) != 1:
+		return ErrSyntax
+	}
+	if err := (*BoolValue)(&b.Value).Set(f[0]); err != nil {
+		return err
+	}
+	*v = TemporaryBoolValue(b)
+	return nil
+}
+
+func (v *TemporaryBoolValue) String() string {
+	s := strconv.FormatBool(v.Value)
+	if !v.Until.IsZero() {
+		s += " until " + v.Until.Format(time.RFC3339)
+	}
+	return s
+}
+
+// RegexpValue represents a configuration variable's regular expression
+// value, compiled with regexp.Compile.
+type RegexpValue struct {
+	target **regexp.Regexp
+}
+
+// NewRegexpValue returns a RegexpValue setting target.
+func NewRegexpValue(target **regexp.Regexp) *RegexpValue {
+	return &RegexpValue{target}
+}
+
+func (v *RegexpValue) Set(s string) error {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	*v.target = re
+	return nil
+}
+
+// String returns the source text of the regular expression.
+func (v *RegexpValue) String() string {
+	if *v.target == nil {
+		return ""
+	}
+	return (*v.target).String()
+}
+
+func (v *RegexpValue) Reset() { *v.target = nil }
+
+func (v *RegexpValue) clone() Value {
+	return &RegexpValue{new(*regexp.Regexp)}
+}
+
+// IPValue represents a configuration variable's IP address value.
+// Both IPv4 and IPv6 addresses are accepted, the latter optionally
+// enclosed in square brackets, like "[::1]".
+type IPValue net.IP
+
+func (v *IPValue) Set(s string) error {
+	if len(s) > 2 && s[0] == '[' && s[len(s)-1] == ']' {
+		s = s[1 : len(s)-1]
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return errors.New("invalid IP address")
+	}
+	*v = IPValue(ip)
+	return nil
+}
+
+func (v *IPValue) String() string { return net.IP(*v).String() }
+
+// IPNetValue represents a configuration variable's IP network value
+// in CIDR notation, like "192.168.0.0/16" or "2001:db8::/32".
+// The network address is stored, i.e., "192.168.1.1/16" is the same
+// as "192.168.0.0/16".
+type IPNetValue net.IPNet
+
+func (v *IPNetValue) Set(s string) error {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return err
+	}
+	*v = IPNetValue(*n)
+	return nil
+}
+
+func (v *IPNetValue) String() string { return (*net.IPNet)(v).String() }
+
+// byteUnits lists ByteSizeValue units from the largest.
+var byteUnits = []struct {
+	name string
+	size int64
+}{
+	{"EiB", 1 << 60}, {"EB", 1e18},
+	{"PiB", 1 << 50}, {"PB", 1e15},
+	{"TiB", 1 << 40}, {"TB", 1e12},
+	{"GiB", 1 << 30}, {"GB", 1e9},
+	{"MiB", 1 << 20}, {"MB", 1e6},
+	{"KiB", 1 << 10}, {"KB", 1e3},
+	{"B", 1},
+}
+
+var byteSizeRE = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?|\.[0-9]+)\pZ*([a-zA-Z]*)$`)
+
+// ByteSizeValue represents a configuration variable's size in bytes.
+// Syntax: a non-negative decimal number, possibly fractional,
+// optionally followed by a unit: B, decimal KB, MB, GB, TB, PB, EB
+// (powers of 1000), or binary KiB, MiB, GiB, TiB, PiB, EiB (powers
+// of 1024), case insensitive, with optional space before it.
+// Fractions of a byte are truncated.  For example: "1024", "10MB",
+// "1.5 MiB".
+type ByteSizeValue int64
+
+func (v *ByteSizeValue) Set(s string) error {
+	m := byteSizeRE.FindStringSubmatch(s)
+	if m == nil {
+		return ErrSyntax
+	}
+	size := int64(1)
+	if m[2] != "" {
+		size = 0
+		for _, u := range byteUnits {
+			if strings.EqualFold(m[2], u.name) {
+				size = u.size
+				break
+			}
+		}
+		if size == 0 {
+			return errors.New("unknown unit " + m[2])
+		}
+	}
+	r, _ := new(big.Rat).SetString(m[1])
+	r.Mul(r, new(big.Rat).SetInt64(size))
+	n := new(big.Int).Quo(r.Num(), r.Denom())
+	if !n.IsInt64() {
+		return strconv.ErrRange
+	}
+	*v = ByteSizeValue(n.Int64())
+	return nil
+}
+
+// String returns the size in the largest unit it's a whole multiple of.
+func (v *ByteSizeValue) String() string {
+	for _, u := range byteUnits {
+		if *v != 0 && int64(*v)%u.size == 0 {
+			return strconv.FormatInt(int64(*v)/u.size, 10) + u.name
+		}
+	}
+	return "0"
+}
+
+// PercentValue represents a configuration variable's ratio given as
+// a percentage, like "75%", which sets the variable to 0.75.  The '%'
+// is optional.  Values outside of 0% to 100% are rejected with
+// strconv.ErrRange, unless created by NewUnboundedPercentValue.
+type PercentValue struct {
+	target    *float64
+	unbounded bool
+}
+
+// NewPercentValue returns a PercentValue setting target to a ratio
+// from 0 to 1, given as 0% to 100%.
+func NewPercentValue(target *float64) *PercentValue {
+	return &PercentValue{target, false}
+}
+
+// NewUnboundedPercentValue is like NewPercentValue, but allows any
+// percentage, like "-5%" or "150%".
+func NewUnboundedPercentValue(target *float64) *PercentValue {
+	return &PercentValue{target, true}
+}
+
+func (v *PercentValue) Set(s string) error {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil {
+		return err.(*strconv.NumError).Err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.ErrSyntax
+	}
+	if !v.unbounded && (f < 0 || f > 100) {
+		return fmt.Errorf("%w, must be from 0%% to 100%%", strconv.ErrRange)
+	}
+	*v.target = f / 100
+	return nil
+}
+
+// String returns the percentage followed by '%'.  It's rounded to 15
+// significant digits, hiding floating point error, as in "7%" for 0.07.
+func (v *PercentValue) String() string {
+	return strconv.FormatFloat(*v.target*100, 'g', 15, 64) + "%"
+}
+
+func (v *PercentValue) Reset() { *v.target = 0 }
+
+func (v *PercentValue) clone() Value {
+	return &PercentValue{new(float64), v.unbounded}
+}
+
+type unit struct {
+	name string
+	size int64
+}
+
+// UnitValue represents a configuration variable's integer value in
+// arbitrary units, given as an integer optionally followed by a unit
+// name, like "300" or "5m".  The integer is parsed like in Int64Value,
+// so "0x10" and "0777" are hexadecimal and octal.  Unit names are
+// matched first, so with a unit named "d", "0x1d" is one "d", not
+// 29; hexadecimal numbers ending in digits clashing with unit names
+// can be given in the other case, like "0x1D".
+type UnitValue struct {
+	target *int64
+	units  []unit // sorted by size, largest first
+}
+
+// NewUnitValue returns a UnitValue setting target to a number
+// multiplied by the size of its unit in units, e.g., in seconds:
+//
+//	NewUnitValue(&secs, map[string]int64{"s": 1, "m": 60, "h": 3600})
+//
+// Unit names are case sensitive.
+func NewUnitValue(target *int64, units map[string]int64) *UnitValue {
+	v := &UnitValue{target, nil}
+	for name, size := range units {
+		v.units = append(v.units, unit{name, size})
+	}
+	sort.Slice(v.units, func(i, j int) bool {
+		a, b := v.units[i], v.units[j]
+		return a.size > b.size || a.size == b.size && a.name < b.name
+	})
+	return v
+}
+
+func (v *UnitValue) Set(s string) error {
+	// units first, as their names may be hex digits
+	var (
+		n, size int64
+		name    string
+	)
+	for _, u := range v.units {
+		if len(u.name) <= len(name) || !strings.HasSuffix(s, u.name) {
+			continue
+		}
+		if m, err := strconv.ParseInt(s[:len(s)-len(u.name)], 0, 64); err == nil {
+			n, size, name = m, u.size, u.name
+		}
+	}
+	if name == "" {
+		m, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return err.(*strconv.NumError).Err
+		}
+		n, size = m, 1
+	}
+	if size != 0 && (n > math.MaxInt64/size || n < math.MinInt64/size) {
+		return strconv.ErrRange
+	}
+	*v.target = n * size
+	return nil
+}
+
+// String returns the number in the largest unit it's a whole multiple of.
+func (v *UnitValue) String() string {
+	for _, u := range v.units {
+		if *v.target != 0 && u.size > 0 && *v.target%u.size == 0 {
+			return strconv.FormatInt(*v.target/u.size, 10) + u.name
+		}
+	}
+	return strconv.FormatInt(*v.target, 10)
+}
+
+func (v *UnitValue) Reset() { *v.target = 0 }
+
+func (v *UnitValue) clone() Value { return &UnitValue{new(int64), v.units} }
+
+// TimeValue represents a configuration variable's time value.
+// RFC 3339 timestamps, like 2024-01-02T15:04:05Z, can be given
+// as plain values.
+type TimeValue struct {
+	target *time.Time
+	layout string
+}
+
+// NewTimeValue returns a TimeValue setting target to an RFC 3339
+// timestamp.
+func NewTimeValue(target *time.Time) *TimeValue {
+	return &TimeValue{target, time.RFC3339}
+}
+
+// NewTimeValueLayout returns a TimeValue setting target to a time
+// in the given layout, as understood by time.Parse.
+func NewTimeValueLayout(target *time.Time, layout string) *TimeValue {
+	return &TimeValue{target, layout}
+}
+
+func (v *TimeValue) Set(s string) error {
+	t, err := time.Parse(v.layout, s)
+	if err != nil {
+		return err
+	}
+	*v.target = t
+	return nil
+}
+
+func (v *TimeValue) String() string { return v.target.Format(v.layout) }
+
+func (v *TimeValue) Reset() { *v.target = time.Time{} }
+
+func (v *TimeValue) clone() Value { return &TimeValue{new(time.Time), v.layout} }
+
+// MapValue represents a configuration variable's map value, given
+// as a list of key-value pairs, like "env=prod,team=core".  Entries
+// are split on the first pair separator, so values may contain it,
+// while keys may not; values may be empty, keys may not.  Whitespace
+// around keys and values is ignored.  Each call to Set adds entries
+// to the map, so, like StringSliceValue, a Var having a MapValue may
+// be set more than once.
+type MapValue struct {
+	target      *map[string]string
+	entry, pair string
+}
+
+// NewMapValue returns a MapValue adding entries to target, with
+// entries separated by ',' and keys separated from values by '='.
+// If *target is nil, a new map is allocated on first Set.
+func NewMapValue(target *map[string]string) *MapValue {
+	return &MapValue{target, ",", "="}
+}
+
+// NewMapValueSep is like NewMapValue, but with the given entry
+// and pair separators.
+func NewMapValueSep(target *map[string]string, entry, pair string) *MapValue {
+	return &MapValue{target, entry, pair}
+}
+
+func (v *MapValue) Set(s string) error {
+	m := make(map[string]string)
+	for _, e := range strings.Split(s, v.entry) {
+		i := strings.Index(e, v.pair)
+		if i == -1 {
+			return fmt.Errorf("entry %q: missing %q", e, v.pair)
+		}
+		key := strings.TrimSpace(e[:i])
+		if key == "" {
+			return fmt.Errorf("entry %q: empty key", e)
+		}
+		m[key] = strings.TrimSpace(e[i+len(v.pair):])
+	}
+	if *v.target == nil {
+		*v.target = m
+		return nil
+	}
+	for key, val := range m {
+		(*v.target)[key] = val
+	}
+	return nil
+}
+
+// String returns the entries sorted by key.
+func (v *MapValue) String() string {
+	s := make([]string, 0, len(*v.target))
+	for key, val := range *v.target {
+		s = append(s, key+v.pair+val)
+	}
+	sort.Strings(s)
+	return strings.Join(s, v.entry)
+}
+
+func (v *MapValue) multi() {}
+
+func (v *MapValue) Reset() { *v.target = nil }
+
+func (v *MapValue) clone() Value {
+	return &MapValue{new(map[string]string), v.entry, v.pair}
+}
+
+// IntSliceValue represents a configuration variable's list of integer
+// values, given separated by commas (or another separator), like
+// "80,443,8080".  An element may be a range "low-high", like
+// "8000-8003", standing for all the integers in it.  A range may
+// have at most MaxIntRange integers.  Whitespace around elements is
+// ignored.  Each call to Set appends to the list, so, like
+// StringSliceValue, a Var having an IntSliceValue may be set more
+// than once.
+type IntSliceValue struct {
+	target *[]int
+	sep    string
+}
+
+// NewIntSliceValue returns an IntSliceValue appending to target,
+// with elements separated by commas.
+func NewIntSliceValue(target *[]int) *IntSliceValue {
+	return &IntSliceValue{target, ","}
+}
+
+// NewIntSliceValueSep is like NewIntSliceValue, but with elements
+// separated by sep.
+func NewIntSliceValueSep(target *[]int, sep string) *IntSliceValue {
+	return &IntSliceValue{target, sep}
+}
+
+// MaxIntRange is the maximum number of integers in a range given
+// to IntSliceValue, enough for "0-65535".  Larger ranges are errors,
+// so that a typo can't make Set exhaust memory.
+const MaxIntRange = 1 << 16
+
+// atoi is strconv.Atoi with the error stripped of fluff.
+func atoi(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err.(*strconv.NumError).Err
+	}
+	return n, nil
+}
+
+func (v *IntSliceValue) Set(s string) error {
+	var l []int
+	for _, e := range strings.Split(s, v.sep) {
+		e = strings.TrimSpace(e)
+		n, err := atoi(e)
+		if err == nil {
+			l = append(l, n)
+			continue
+		}
+		i := 0
+		if e != "" {
+			// skip the sign of the low end
+			i = strings.Index(e[1:], "-") + 1
+		}
+		if i == 0 {
+			return fmt.Errorf("element %s: %v", e, err)
+		}
+		lo, err := atoi(strings.TrimSpace(e[:i]))
+		if err != nil {
+			return fmt.Errorf("element %s: %v", e, err)
+		}
+		hi, err := atoi(strings.TrimSpace(e[i+1:]))
+		if err != nil {
+			return fmt.Errorf("element %s: %v", e, err)
+		}
+		if lo > hi {
+			return fmt.Errorf("element %s: invalid range", e)
+		}
+		// hi-lo may overflow int, but not uint
+		if uint(hi)-uint(lo) >= MaxIntRange {
+			return fmt.Errorf("element %s: range too large", e)
+		}
+		for n := lo; n <= hi && n >= lo; n++ {
+			l = append(l, n)
+		}
+	}
+	*v.target = append(*v.target, l...)
+	return nil
+}
+
+func (v *IntSliceValue) String() string {
+	s := make([]string, len(*v.target))
+	for i, n := range *v.target {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, v.sep)
+}
+
+func (v *IntSliceValue) multi() {}
+
+func (v *IntSliceValue) Reset() { *v.target = nil }
+
+func (v *IntSliceValue) clone() Value { return &IntSliceValue{new([]int), v.sep} }
+
+type sliceValue struct {
+	newElem func() Value
+	add     func(Value)
+	sep     string
+}
+
+func (v sliceValue) Set(s string) error {
+	var l []Value
+	for _, e := range strings.Split(s, v.sep) {
+		e = strings.TrimSpace(e)
+		elem := v.newElem()
+		if err := elem.Set(e); err != nil {
+			return fmt.Errorf("element %s: %v", e, err)
+		}
+		l = append(l, elem)
+	}
+	for _, elem := range l {
+		v.add(elem)
+	}
+	return nil
+}
+
+func (v sliceValue) multi() {}
+
+// clone parses elements without adding them.
+func (v sliceValue) clone() Value {
+	return sliceValue{v.newElem, func(Value) {}, v.sep}
+}
+
+// SliceValue returns a Value parsing a comma separated list of
+// elements, each set by a fresh Value returned by newElem and passed
+// to add, which is expected to append it to a slice.  Whitespace
+// around elements is ignored.  If any element fails to parse, none
+// is added.  For example, for a list of durations:
+//
+//	var timeouts []time.Duration
+//	conf.SliceValue(func() conf.Value { return new(conf.DurationValue) },
+//		func(v conf.Value) {
+//			timeouts = append(timeouts,
+//				time.Duration(*v.(*conf.DurationValue)))
+//		})
+//
+// Like StringSliceValue, a Var having a SliceValue may be set more
+// than once.  As the slice is unknown to it, a SliceValue is not
+// a Resetter, and the slice must be cleared before reparsing.
+func SliceValue(newElem func() Value, add func(Value)) Value {
+	return sliceValue{newElem, add, ","}
+}
+
+// SliceValueSep is like SliceValue, but with elements separated
+// by sep.
+func SliceValueSep(newElem func() Value, add func(Value), sep string) Value {
+	return sliceValue{newElem, add, sep}
+}
+
+// Base64Value represents a configuration variable's binary value
+// encoded in base64.  Padded values end in '=', which can't appear
+// in plain values, so they usually need to be quoted:
+//
+//	key = "aGVsbG8="
+type Base64Value struct {
+	target *[]byte
+	enc    *base64.Encoding
+}
+
+// NewBase64Value returns a Base64Value setting target to a value
+// in standard base64 encoding.
+func NewBase64Value(target *[]byte) *Base64Value {
+	return &Base64Value{target, base64.StdEncoding}
+}
+
+// NewBase64ValueEncoding is like NewBase64Value, but with the given
+// encoding, such as base64.URLEncoding or base64.RawStdEncoding.
+func NewBase64ValueEncoding(target *[]byte, enc *base64.Encoding) *Base64Value {
+	return &Base64Value{target, enc}
+}
+
+func (v *Base64Value) Set(s string) error {
+	b, err := v.enc.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*v.target = b
+	return nil
+}
+
+func (v *Base64Value) String() string {
+	return v.enc.EncodeToString(*v.target)
+}
+
+func (v *Base64Value) Reset() { *v.target = nil }
+
+func (v *Base64Value) clone() Value { return &Base64Value{new([]byte), v.enc} }
+
+// HexBytesValue represents a configuration variable's binary value
+// encoded in hexadecimal, like "0a1b2c", optionally of fixed length.
+type HexBytesValue struct {
+	target *[]byte
+	size   int
+}
+
+// NewHexBytesValue returns a HexBytesValue setting target.  If size
+// is positive, the value must be exactly size bytes (2*size digits)
+// long.
+func NewHexBytesValue(target *[]byte, size int) *HexBytesValue {
+	return &HexBytesValue{target, size}
+}
+
+func (v *HexBytesValue) Set(s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if v.size > 0 && len(b) != v.size {
+		return fmt.Errorf("invalid length, must be %d bytes "+
+			"(%d hexadecimal digits)", v.size, 2*v.size)
+	}
+	*v.target = b
+	return nil
+}
+
+func (v *HexBytesValue) String() string { return hex.EncodeToString(*v.target) }
+
+func (v *HexBytesValue) Reset() { *v.target = nil }
+
+func (v *HexBytesValue) clone() Value { return &HexBytesValue{new([]byte), v.size} }
+
+// OptionalValue wraps another Value, recording whether it has been
+// set, e.g., to tell a port that is not set from one set to 0.
+// Unlike WasSet, the record is kept in the Value itself and is not
+// cleared by Reset.  Setting the Var to its Default counts as setting
+// it, so Vars with OptionalValue normally have no Default.
+type OptionalValue struct {
+	Value // wrapped Value
+	set   bool
+}
+
+// NewOptionalValue returns an OptionalValue wrapping v.
+func NewOptionalValue(v Value) *OptionalValue {
+	return &OptionalValue{Value: v}
+}
+
+// Set calls Set of the wrapped Value and, if it succeeds, records
+// that the value has been set.
+func (v *OptionalValue) Set(s string) error {
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	v.set = true
+	return nil
+}
+
+func (v *OptionalValue) String() string {
+	s, _ := ValueString(v.Value)
+	return s
+}
+
+// IsSet reports whether the value has been set.
+func (v *OptionalValue) IsSet() bool { return v.set }
+
+// Reset calls Reset of the wrapped Value, if it's a Resetter.
+func (v *OptionalValue) Reset() {
+	if r, ok := v.Value.(Resetter); ok {
+		r.Reset()
+	}
+}
+
+func (v *OptionalValue) clone() Value {
+	c := cloneValue(v.Value)
+	if c == nil {
+		return nil
+	}
+	return &OptionalValue{Value: c}
+}
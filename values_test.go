@@ -0,0 +1,684 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"encoding/base64"
+	"errors"
+	"os/user"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var intSliceTests = []struct {
+	in  string
+	out []int
+	ok  bool
+}{
+	{"80,443,8080", []int{80, 443, 8080}, true},
+	{" 1 , 2 ", []int{1, 2}, true},
+	{"8000-8003", []int{8000, 8001, 8002, 8003}, true},
+	{"-3--1", []int{-3, -2, -1}, true},
+	{"5-5", []int{5}, true},
+	{"0-65535", nil, true},
+	{"3-1", nil, false},
+	{"1,x", nil, false},
+	{"", nil, false},
+	{"0-65536", nil, false},
+	{"0-9223372036854775806", nil, false},
+	{"-9223372036854775808-9223372036854775807", nil, false},
+}
+
+func TestIntSliceValue(t *testing.T) {
+	for _, test := range intSliceTests {
+		var l []int
+		err := NewIntSliceValue(&l).Set(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v, want ok = %v", test.in, err, test.ok)
+			continue
+		}
+		if test.out != nil && !reflect.DeepEqual(l, test.out) {
+			t.Errorf("%q: got %v, want %v", test.in, l, test.out)
+		}
+		if !test.ok && l != nil {
+			t.Errorf("%q: got %v on error", test.in, l)
+		}
+	}
+}
+
+func TestIntSliceValueAppend(t *testing.T) {
+	var l []int
+	vars := []Var{{Name: "p", Val: NewIntSliceValueSep(&l, ";")}}
+	if err := ParseString("p = 1;2\np = 3-4\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(l, want) {
+		t.Errorf("got %v, want %v", l, want)
+	}
+}
+
+var unitTests = []struct {
+	in  string
+	out int64
+	ok  bool
+}{
+	{"300", 300, true},
+	{"5m", 300, true},
+	{"2d", 172800, true},
+	{"0x10", 16, true},
+	{"0x10m", 960, true},
+	{"0x1d", 86400, true},
+	{"0x1D", 29, true},
+	{"010", 8, true},
+	{"0777", 511, true},
+	{"-1h", -3600, true},
+	{"5x", 0, false},
+	{"m", 0, false},
+	{"", 0, false},
+	{"9223372036854775807d", 0, false},
+}
+
+func TestUnitValue(t *testing.T) {
+	var n int64
+	v := NewUnitValue(&n, map[string]int64{
+		"s": 1, "m": 60, "h": 3600, "d": 86400,
+	})
+	for _, test := range unitTests {
+		n = 0
+		err := v.Set(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v, want ok = %v", test.in, err, test.ok)
+		} else if n != test.out {
+			t.Errorf("%q: got %d, want %d", test.in, n, test.out)
+		}
+	}
+}
+
+func TestEnumValue(t *testing.T) {
+	var s string
+	v := NewEnumValue(&s, "debug", "info", "warn")
+	if err := v.Set("info"); err != nil || s != "info" || v.String() != "info" {
+		t.Errorf("Set(info): got %q, %v", s, err)
+	}
+	if err := v.Set("INFO"); err == nil {
+		t.Error("case sensitive EnumValue accepted INFO")
+	}
+	vars := []Var{{Name: "log-level", Val: v}}
+	err := ParseString("log-level = verbose\n", "", vars)
+	if err == nil || !strings.Contains(err.Error(), "debug, info, warn") {
+		t.Errorf("got %v, want error listing choices", err)
+	}
+	if s != "info" {
+		t.Errorf("failed Set changed value to %q", s)
+	}
+	f := NewEnumValueFold(&s, "debug", "info", "warn")
+	if err := f.Set("WARN"); err != nil || s != "warn" {
+		t.Errorf("fold Set(WARN): got %q, %v, want \"warn\"", s, err)
+	}
+}
+
+func TestUserValue(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skip("can't look up current user:", err)
+	}
+	for _, s := range []string{u.Username, u.Uid} {
+		var v UserValue = -1
+		if err := v.Set(s); err != nil {
+			t.Errorf("%q: %v", s, err)
+		} else if v.String() != u.Uid {
+			t.Errorf("%q: got %s, want %s", s, v.String(), u.Uid)
+		}
+	}
+	var v UserValue
+	if err := v.Set("no-such-user-here"); err == nil {
+		t.Error("unknown user accepted")
+	}
+}
+
+func TestGroupValue(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Skip("can't look up current user:", err)
+	}
+	g, err := user.LookupGroupId(u.Gid)
+	if err != nil {
+		t.Skip("can't look up current group:", err)
+	}
+	for _, s := range []string{g.Name, g.Gid} {
+		var v GroupValue = -1
+		if err := v.Set(s); err != nil {
+			t.Errorf("%q: %v", s, err)
+		} else if v.String() != g.Gid {
+			t.Errorf("%q: got %s, want %s", s, v.String(), g.Gid)
+		}
+	}
+	var v GroupValue
+	if err := v.Set("no-such-group-here"); err == nil {
+		t.Error("unknown group accepted")
+	}
+}
+
+func TestTimezoneValue(t *testing.T) {
+	var loc *time.Location
+	v := NewTimezoneValue(&loc)
+	names := []string{"UTC", "Local"}
+	if _, err := time.LoadLocation("America/New_York"); err == nil {
+		names = append(names, "America/New_York")
+	}
+	for _, name := range names {
+		if err := v.Set(name); err != nil {
+			t.Errorf("%q: %v", name, err)
+		} else if v.String() != name {
+			t.Errorf("%q: got %q", name, v.String())
+		}
+	}
+	loc = time.UTC
+	for _, name := range []string{"Mars/Olympus_Mons", ""} {
+		if err := v.Set(name); err == nil {
+			t.Errorf("%q accepted", name)
+		}
+	}
+	if loc != time.UTC {
+		t.Errorf("failed Set changed location to %v", loc)
+	}
+}
+
+func TestRegexpValue(t *testing.T) {
+	var re *regexp.Regexp
+	v := NewRegexpValue(&re)
+	vars := []Var{{Name: "match", Val: v}}
+	if err := ParseString(`match = "^a+b$"`, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if !re.MatchString("aab") || v.String() != "^a+b$" {
+		t.Errorf("got %q", v)
+	}
+	vars = []Var{{Name: "match", Val: NewRegexpValue(&re)}}
+	err := ParseString("match = a(b\n", "", vars)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Ident != "match" {
+		t.Errorf("got %#v, want ParseError for match", err)
+	}
+}
+
+var ipTests = []struct {
+	in, out string
+	ok      bool
+}{
+	{"192.168.1.1", "192.168.1.1", true},
+	{"::1", "::1", true},
+	{"[::1]", "::1", true},
+	{"[2001:db8::1]", "2001:db8::1", true},
+	{"", "", false},
+	{"[]", "", false},
+	{"192.168.1", "", false},
+	{"[192.168.1.1", "", false},
+}
+
+func TestIPValue(t *testing.T) {
+	for _, test := range ipTests {
+		var v IPValue
+		err := v.Set(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v", test.in, err)
+		} else if test.ok && v.String() != test.out {
+			t.Errorf("%q: got %q, want %q", test.in, &v, test.out)
+		}
+	}
+}
+
+var ipNetTests = []struct {
+	in, out string
+	ok      bool
+}{
+	{"192.168.0.0/16", "192.168.0.0/16", true},
+	{"192.168.1.1/16", "192.168.0.0/16", true},
+	{"::1/128", "::1/128", true},
+	{"2001:db8::/32", "2001:db8::/32", true},
+	{"", "", false},
+	{"192.168.0.0", "", false},
+	{"192.168.0.0/33", "", false},
+}
+
+func TestIPNetValue(t *testing.T) {
+	for _, test := range ipNetTests {
+		var v IPNetValue
+		err := v.Set(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q: got error %v", test.in, err)
+		} else if test.ok && v.String() != test.out {
+			t.Errorf("%q: got %q, want %q", test.in, &v, test.out)
+		}
+	}
+}
+
+var byteSizeTests = []struct {
+	in  string
+	n   ByteSizeValue
+	out string
+	err error
+}{
+	{"1.5MiB", 3 << 19, "1536KiB", nil},
+	{"1.5 mib", 3 << 19, "1536KiB", nil},
+	{"1024", 1024, "1KiB", nil},
+	{"10MB", 10e6, "10MB", nil},
+	{"0", 0, "0", nil},
+	{"1023B", 1023, "1023B", nil},
+	{"99999999999999TB", 0, "", strconv.ErrRange},
+	{"10XB", 0, "", nil},
+	{"MB", 0, "", ErrSyntax},
+	{"-1", 0, "", ErrSyntax},
+}
+
+func TestByteSizeValue(t *testing.T) {
+	for _, test := range byteSizeTests {
+		var v ByteSizeValue
+		err := v.Set(test.in)
+		switch {
+		case test.out == "":
+			if err == nil ||
+				test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("%q: got error %v, want %v",
+					test.in, err, test.err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", test.in, err)
+		case v != test.n || v.String() != test.out:
+			t.Errorf("%q: got %d (%s), want %d (%s)", test.in,
+				v, &v, test.n, test.out)
+		}
+	}
+}
+
+func TestTimeValue(t *testing.T) {
+	var tm time.Time
+	vars := []Var{{Name: "start-after", Val: NewTimeValue(&tm)}}
+	err := ParseString("start-after = 2024-01-02T15:04:05Z\n", "", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	if !tm.Equal(want) {
+		t.Errorf("got %v, want %v", tm, want)
+	}
+	if s := vars[0].Val.(*TimeValue).String(); s != "2024-01-02T15:04:05Z" {
+		t.Errorf("String: got %q", s)
+	}
+	for _, s := range []string{"2024-02-30T15:04:05Z", "2024-01-02", ""} {
+		if err := NewTimeValue(&tm).Set(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+	v := NewTimeValueLayout(&tm, "2006-01-02")
+	if err := v.Set("2024-01-02"); err != nil {
+		t.Error(err)
+	} else if s := v.String(); s != "2024-01-02" {
+		t.Errorf("String: got %q", s)
+	}
+	if err := v.Set("2024-13-01"); err == nil {
+		t.Error("2024-13-01: no error")
+	}
+}
+
+func TestMapValue(t *testing.T) {
+	var m map[string]string
+	vars := []Var{{Name: "labels", Val: NewMapValue(&m)}}
+	const in = "labels = \"env=prod, team = core\"\nlabels = \"team=infra,q=a=b,empty=\"\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"env": "prod", "team": "infra", "q": "a=b", "empty": "",
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %q, want %q", m, want)
+	}
+	if s := vars[0].Val.(*MapValue).String(); s != "empty=,env=prod,q=a=b,team=infra" {
+		t.Errorf("String: got %q", s)
+	}
+	for _, s := range []string{"env", "a=1,b", "=x", "a=1,"} {
+		m = nil
+		if err := NewMapValue(&m).Set(s); err == nil {
+			t.Errorf("%q: no error", s)
+		} else if m != nil {
+			t.Errorf("%q: map changed to %q", s, m)
+		}
+	}
+	m = nil
+	want = map[string]string{"a": "1", "b": "x=y"}
+	if err := NewMapValueSep(&m, ";", ":").Set("a:1;b:x=y"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %q, want %q", m, want)
+	}
+}
+
+func TestBase64Value(t *testing.T) {
+	var b []byte
+	vars := []Var{{Name: "key", Val: NewBase64Value(&b)}}
+	if err := ParseString(`key = "aGVsbG8="`, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("got %q, want \"hello\"", b)
+	}
+	if s := vars[0].Val.(*Base64Value).String(); s != "aGVsbG8=" {
+		t.Errorf("String: got %q", s)
+	}
+	if err := NewBase64Value(&b).Set("aGVsbG8"); err == nil {
+		t.Error("unpadded: no error")
+	}
+	v := NewBase64ValueEncoding(&b, base64.RawURLEncoding)
+	if err := v.Set("_-8"); err != nil {
+		t.Error(err)
+	} else if string(b) != "\xff\xef" || v.String() != "_-8" {
+		t.Errorf("raw URL: got %q, %q", b, v)
+	}
+}
+
+func TestOptionalValue(t *testing.T) {
+	var port, timeout int64
+	vars := []Var{
+		{Name: "port", Val: NewOptionalValue((*Int64Value)(&port))},
+		{Name: "timeout", Val: NewOptionalValue((*Int64Value)(&timeout))},
+	}
+	if err := ParseString("port = 0\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	p := vars[0].Val.(*OptionalValue)
+	tm := vars[1].Val.(*OptionalValue)
+	if !p.IsSet() || port != 0 {
+		t.Errorf("port: got %d, set %v, want 0, set", port, p.IsSet())
+	}
+	if tm.IsSet() || timeout != 0 {
+		t.Errorf("timeout: got %d, set %v, want 0, unset",
+			timeout, tm.IsSet())
+	}
+	if err := tm.Set("x"); err == nil || tm.IsSet() {
+		t.Errorf("bad value: got %v, set %v", err, tm.IsSet())
+	}
+	Reset(vars)
+	if !p.IsSet() {
+		t.Error("Reset cleared IsSet")
+	}
+}
+
+func TestLevelValue(t *testing.T) {
+	var level int
+	v := NewLevelValue(&level, map[string]int{
+		"debug": 0, "info": 1, "warn": 2, "error": 3,
+	})
+	vars := []Var{{Name: "log-level", Val: v}}
+	if err := ParseString("log-level = WARN\n", "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if level != 2 || v.String() != "warn" {
+		t.Errorf("got %d (%s), want 2 (warn)", level, v)
+	}
+	err := v.Set("verbose")
+	if err == nil || level != 2 {
+		t.Fatalf("verbose: got %v, level %d", err, level)
+	}
+	if want := "debug, info, warn, error"; !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("got %q, want it to list %q", err, want)
+	}
+	level = 7
+	if s := v.String(); s != "7" {
+		t.Errorf("String: got %q, want \"7\"", s)
+	}
+}
+
+var intRangeTests = []struct {
+	in string
+	n  int64
+	ok bool
+}{
+	{"1", 1, true},
+	{"65535", 65535, true},
+	{"0xffff", 65535, true},
+	{"0", 0, false},
+	{"65536", 0, false},
+	{"-1", 0, false},
+}
+
+func TestIntRangeValue(t *testing.T) {
+	for _, test := range intRangeTests {
+		var n int64
+		vars := []Var{{Name: "port", Val: NewIntRangeValue(&n, 1, 65535)}}
+		err := ParseString("port = "+test.in, "", vars)
+		var pe *ParseError
+		switch {
+		case test.ok && err != nil:
+			t.Errorf("%q: %v", test.in, err)
+		case test.ok && n != test.n:
+			t.Errorf("%q: got %d, want %d", test.in, n, test.n)
+		case !test.ok && (!errors.As(err, &pe) || pe.Ident != "port" ||
+			!errors.Is(err, strconv.ErrRange)):
+			t.Errorf("%q: got %v, want ErrRange for port", test.in, err)
+		case !test.ok && !strings.Contains(err.Error(), "from 1 to 65535"):
+			t.Errorf("%q: error %q doesn't name the bounds", test.in, err)
+		}
+	}
+}
+
+func TestUintRangeValue(t *testing.T) {
+	var n uint64
+	v := NewUintRangeValue(&n, 1, 64)
+	for _, s := range []string{"1", "64"} {
+		if err := v.Set(s); err != nil || v.String() != s {
+			t.Errorf("%q: got %d, %v", s, n, err)
+		}
+	}
+	for _, s := range []string{"0", "65", "-1"} {
+		if err := v.Set(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+	if err := v.Set("65"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("got %v, want ErrRange", err)
+	}
+}
+
+func TestSliceValue(t *testing.T) {
+	var timeouts []time.Duration
+	newElem := func() Value { return new(DurationValue) }
+	add := func(v Value) {
+		timeouts = append(timeouts, time.Duration(*v.(*DurationValue)))
+	}
+	vars := []Var{{Name: "timeouts", Val: SliceValue(newElem, add)}}
+	const in = "timeouts = \"1s, 2m\"\ntimeouts = 3h\n"
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{time.Second, 2 * time.Minute, 3 * time.Hour}
+	if !reflect.DeepEqual(timeouts, want) {
+		t.Errorf("got %v, want %v", timeouts, want)
+	}
+
+	timeouts = nil
+	if err := SliceValue(newElem, add).Set("1s,x,2s"); err == nil ||
+		timeouts != nil {
+		t.Errorf("bad element: got %v, %v", timeouts, err)
+	}
+	want = []time.Duration{time.Second, 2 * time.Second}
+	if err := SliceValueSep(newElem, add, ":").Set("1s:2s"); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(timeouts, want) {
+		t.Errorf("got %v, want %v", timeouts, want)
+	}
+}
+
+var percentTests = []struct {
+	in        string
+	f         float64
+	out       string
+	unbounded bool
+	err       error
+}{
+	{"75%", 0.75, "75%", false, nil},
+	{"0%", 0, "0%", false, nil},
+	{"100%", 1, "100%", false, nil},
+	{"7", 0.07, "7%", false, nil},
+	{"12.5%", 0.125, "12.5%", false, nil},
+	{"150%", 0, "", false, strconv.ErrRange},
+	{"-1%", 0, "", false, strconv.ErrRange},
+	{"150%", 1.5, "150%", true, nil},
+	{"%", 0, "", false, strconv.ErrSyntax},
+	{"NaN%", 0, "", true, strconv.ErrSyntax},
+}
+
+func TestPercentValue(t *testing.T) {
+	for _, test := range percentTests {
+		var f float64
+		v := NewPercentValue(&f)
+		if test.unbounded {
+			v = NewUnboundedPercentValue(&f)
+		}
+		err := v.Set(test.in)
+		switch {
+		case test.err != nil:
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q: got %v, want %v", test.in, err, test.err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", test.in, err)
+		case f != test.f || v.String() != test.out:
+			t.Errorf("%q: got %v (%s), want %v (%s)", test.in,
+				f, v, test.f, test.out)
+		}
+	}
+	var f float64
+	vars := []Var{{Name: "cache-size", Val: NewPercentValue(&f)}}
+	if err := ParseString("cache-size = 75%\n", "", vars); err != nil ||
+		f != 0.75 {
+		t.Errorf("Parse: got %v, %v", f, err)
+	}
+}
+
+var temporaryBoolTests = []struct {
+	in  string
+	out string // String of the result, or "" if Set fails
+}{
+	{"on", "true"},
+	{"false", "false"},
+	{"on until 2024-12-31T00:00:00Z", "true until 2024-12-31T00:00:00Z"},
+	{"yes UNTIL 2024-12-31T01:00:00+01:00",
+		"true until 2024-12-31T01:00:00+01:00"},
+	{"on until 2024-12-31", ""},
+	{"on until tomorrow", ""},
+	{"on until", ""},
+	{"on 2024-12-31T00:00:00Z", ""},
+	{"maybe until 2024-12-31T00:00:00Z", ""},
+	{"", ""},
+}
+
+func TestTemporaryBoolValue(t *testing.T) {
+	for _, test := range temporaryBoolTests {
+		var v TemporaryBoolValue
+		err := v.Set(test.in)
+		if (err == nil) != (test.out != "") {
+			t.Errorf("%q: got error %v", test.in, err)
+		} else if err == nil && v.String() != test.out {
+			t.Errorf("%q: got %q, want %q", test.in, v.String(), test.out)
+		}
+	}
+}
+
+func TestTemporaryBoolActive(t *testing.T) {
+	until := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	before, after := until.Add(-time.Second), until.Add(time.Second)
+	for _, test := range []struct {
+		b           TemporaryBool
+		early, late bool
+	}{
+		{TemporaryBool{true, until}, true, false},
+		{TemporaryBool{true, time.Time{}}, true, true},
+		{TemporaryBool{false, until}, false, false},
+	} {
+		if test.b.Active(before) != test.early ||
+			test.b.Active(until) != test.late ||
+			test.b.Active(after) != test.late {
+			t.Errorf("%+v: got %v, %v, %v", test.b, test.b.Active(before),
+				test.b.Active(until), test.b.Active(after))
+		}
+	}
+}
+
+var emailListTests = []struct {
+	in  string
+	out string
+	err string
+}{
+	{"a@example.com", "<a@example.com>", ""},
+	{"a@example.com, Bob <b@example.com>",
+		`<a@example.com>, "Bob" <b@example.com>`, ""},
+	{"a@example.com, bob", "", "address 2 (bob): "},
+	{"a@example.com,, b@example.com", "", "address 2 (): "},
+	{"", "", "address 1 (): "},
+}
+
+func TestEmailListValue(t *testing.T) {
+	for _, test := range emailListTests {
+		var v EmailListValue
+		err := v.Set(test.in)
+		switch {
+		case test.err != "":
+			if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("%q: got %v, want error starting with %q",
+					test.in, err, test.err)
+			}
+		case err != nil:
+			t.Errorf("%q: %v", test.in, err)
+		case v.String() != test.out:
+			t.Errorf("%q: got %q, want %q", test.in, v.String(), test.out)
+		}
+	}
+}
+
+var hexBytesTests = []struct {
+	in   string
+	size int
+	out  []byte
+	ok   bool
+}{
+	{"0a1b2c", 0, []byte{0x0a, 0x1b, 0x2c}, true},
+	{"0A1B2C", 3, []byte{0x0a, 0x1b, 0x2c}, true},
+	{"", 0, []byte{}, true},
+	{"0a1", 0, nil, false},
+	{"0g", 0, nil, false},
+	{"0a 1b", 0, nil, false},
+	{"0a1b", 3, nil, false},
+	{"0a1b2c3d", 3, nil, false},
+}
+
+func TestHexBytesValue(t *testing.T) {
+	for _, test := range hexBytesTests {
+		var b []byte
+		v := NewHexBytesValue(&b, test.size)
+		err := v.Set(test.in)
+		if (err == nil) != test.ok {
+			t.Errorf("%q, %d: got error %v, want ok = %v",
+				test.in, test.size, err, test.ok)
+		} else if !reflect.DeepEqual(b, test.out) {
+			t.Errorf("%q, %d: got %x, want %x",
+				test.in, test.size, b, test.out)
+		} else if err == nil && v.String() != strings.ToLower(test.in) {
+			t.Errorf("%q: String returned %q", test.in, v.String())
+		}
+	}
+	var b []byte
+	err := NewHexBytesValue(&b, 3).Set("0a1b")
+	if err == nil || !strings.Contains(err.Error(), "3 bytes") {
+		t.Errorf("got %v, want error naming the size", err)
+	}
+}
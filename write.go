@@ -0,0 +1,134 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	errNoString = errors.New("value has no String method")
+	errBadName  = errors.New("name is not a valid identifier")
+)
+
+// QuoteNeeded reports whether s has to be quoted to appear as a value
+// in a configuration file, i.e., whether it's empty or contains any
+// of the characters plain values can't: spaces, control characters,
+// '"', '#', `'`, '=' or `\`.
+func QuoteNeeded(s string) bool {
+	return s == "" || plainRE.FindString(s) != s
+}
+
+// Quote returns s as it should appear as a value in a configuration
+// file: plain if possible, or quoted otherwise, so that parsing it
+// yields s.  The empty string is always quoted, as plain values can't
+// be empty.  Quoted values may not contain control characters (Unicode
+// class C), so these are escaped: strconv.Quote escapes all
+// non-printable runes, and should its idea of printable ever disagree
+// with the grammar, strconv.QuoteToASCII escapes everything beyond
+// printable ASCII.
+func Quote(s string) string {
+	if !QuoteNeeded(s) {
+		return s
+	}
+	if q := strconv.Quote(s); quotedRE.FindString(q) == q {
+		return q
+	}
+	return strconv.QuoteToASCII(s)
+}
+
+// splitName splits the name of a Var into section and identifier,
+// either of which may contain Unicode letters.
+func splitName(name string) (sect, ident string, ok bool) {
+	if i := strings.Index(name, "."); i != -1 {
+		sect, name = name[:i], name[i+1:]
+		if uidentRE.FindString(sect) != sect {
+			return "", "", false
+		}
+	}
+	return sect, name, uidentRE.FindString(name) == name
+}
+
+// Write writes the current values of vars to w in configuration file
+// syntax, one "name = value" line per Var, so that parsing the output
+// with the same vars sets them to the same values.  Vars with dotted
+// names, like "server.port", are written in sections.  Values are
+// obtained with String() and quoted if needed; the elements of
+// a StringSliceValue are written on separate lines.
+//
+// Vars with empty Name (i.e., command line flags) are skipped.
+// A Var whose Value has no String method or whose Name can't
+// be written is an error.  Names containing non-ASCII letters, like
+// "café", are written as is, so parsing the output requires
+// Options.UnicodeIdents.
+func Write(w io.Writer, vars []Var) error {
+	var (
+		sects []string
+		lines = make(map[string][]string)
+	)
+	for i := range vars {
+		v := &vars[i]
+		if v.Name == "" {
+			continue
+		}
+		sect, ident, ok := splitName(v.Name)
+		if !ok {
+			return fmt.Errorf("%s: %w", v.Name, errBadName)
+		}
+		var values []string
+		if val, ok := v.Val.(*StringSliceValue); ok {
+			values = *val
+		} else if s, ok := ValueString(v.Val); ok {
+			values = []string{s}
+		} else {
+			return fmt.Errorf("%s: %w", v.Name, errNoString)
+		}
+		if _, ok := lines[sect]; !ok && sect != "" {
+			sects = append(sects, sect)
+		}
+		for _, s := range values {
+			lines[sect] = append(lines[sect], ident+" = "+Quote(s))
+		}
+	}
+	bw := bufio.NewWriter(w)
+	for _, l := range lines[""] {
+		bw.WriteString(l + "\n")
+	}
+	for _, sect := range sects {
+		bw.WriteString("\n[" + sect + "]\n")
+		for _, l := range lines[sect] {
+			bw.WriteString(l + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// Dump writes the current values of vars to w for humans, one
+// "name = value" line per Var, including Vars with no Name, which
+// are named by their Flag, like "-v".  Values are obtained with
+// ValueString and quoted if needed; Values with no String method
+// are shown as "<unknown>".  Unlike Write, Dump's output is not
+// meant to be parsed.
+func Dump(w io.Writer, vars []Var) error {
+	bw := bufio.NewWriter(w)
+	for i := range vars {
+		s, ok := ValueString(vars[i].Val)
+		if ok {
+			s = Quote(s)
+		} else {
+			s = "<unknown>"
+		}
+		bw.WriteString(label(vars, i) + " = " + s + "\n")
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,206 @@
+// Copyright 2012 Vadim Vygonets
+// This program is free software. It comes without any warranty, to
+// the extent permitted by applicable law. You can redistribute it
+// and/or modify it under the terms of the Do What The Fuck You Want
+// To Public License, Version 2, as published by Sam Hocevar. See
+// the LICENSE file or http://sam.zoy.org/wtfpl/ for more details.
+
+package conf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeVars returns Vars of various types, all bound to fresh
+// variables, and a function returning their values.
+func writeVars() ([]Var, func() []interface{}) {
+	var (
+		s    string
+		b    bool
+		n    int64
+		d    time.Duration
+		l    []string
+		port int64
+	)
+	vars := []Var{
+		{Name: "s", Val: (*StringValue)(&s)},
+		{Name: "b", Val: (*BoolValue)(&b)},
+		{Name: "n", Val: (*Int64Value)(&n)},
+		{Name: "d", Val: (*DurationValue)(&d)},
+		{Name: "l", Val: (*StringSliceValue)(&l)},
+		{Name: "server.port", Val: (*Int64Value)(&port)},
+		{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&b)},
+	}
+	return vars, func() []interface{} {
+		return []interface{}{s, b, n, d, l, port}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	const in = `s = "a \"quoted\" # string"
+b = true
+n = -42
+d = 1m30s
+l = one
+l = "two words"
+l = ""
+
+[server]
+port = 8080
+`
+	vars, values := writeVars()
+	if err := ParseString(in, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	want := values()
+	var buf bytes.Buffer
+	if err := Write(&buf, vars); err != nil {
+		t.Fatal(err)
+	}
+	vars, values = writeVars()
+	if err := ParseBytes(buf.Bytes(), "", vars); err != nil {
+		t.Fatalf("%v\n%s", err, buf.Bytes())
+	}
+	if got := values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q\n%s", got, want, buf.Bytes())
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	for _, vars := range [][]Var{
+		{{Name: "bad name", Val: new(StringValue)}},
+		{{Name: "f", Val: FuncValue(func(string) error { return nil })}},
+	} {
+		var buf bytes.Buffer
+		if err := Write(&buf, vars); err == nil {
+			t.Errorf("%s: no error", vars[0].Name)
+		}
+	}
+}
+
+func TestWriteUnicode(t *testing.T) {
+	var (
+		a, b       = "x", "y"
+		gotA, gotB string
+	)
+	vars := []Var{
+		{Name: "café", Val: (*StringValue)(&a)},
+		{Name: "straße.größe", Val: (*StringValue)(&b)},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, vars); err != nil {
+		t.Fatal(err)
+	}
+	const want = "café = x\n\n[straße]\ngröße = y\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.Bytes(), want)
+	}
+	vars[0].Val, vars[1].Val = (*StringValue)(&gotA), (*StringValue)(&gotB)
+	o := Options{UnicodeIdents: true}
+	if err := o.Parse(&buf, "", vars); err != nil {
+		t.Fatal(err)
+	}
+	if gotA != a || gotB != b {
+		t.Errorf("got %q, %q, want %q, %q", gotA, gotB, a, b)
+	}
+}
+
+func FuzzWriteParse(f *testing.F) {
+	for _, s := range []string{
+		"", "plain", "two words", "tab\there", "new\nline", "#hash",
+		`"quoted"`, `back\slash`, "a=b", "it's", "\x00\x7f\u200b",
+		"\xff invalid", "café",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if len(s) > defaultMaxLine/8 {
+			t.Skip()
+		}
+		var got string
+		vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+		var buf bytes.Buffer
+		if err := Write(&buf, vars); err != nil {
+			t.Fatal(err)
+		}
+		vars = []Var{{Name: "s", Val: (*StringValue)(&got)}}
+		if err := ParseBytes(buf.Bytes(), "", vars); err != nil {
+			t.Fatalf("%q: %v\n%s", s, err, buf.Bytes())
+		}
+		if got != s {
+			t.Errorf("got %q, want %q", got, s)
+		}
+	})
+}
+
+func TestDump(t *testing.T) {
+	var (
+		s   = "two words"
+		n   = int64(42)
+		v   = true
+		str string
+	)
+	vars := []Var{
+		{Name: "s", Val: (*StringValue)(&s)},
+		{Name: "n", Val: (*Int64Value)(&n)},
+		{Flag: 'v', Kind: NoArg, Val: (*BoolValue)(&v)},
+		{Name: "f", Val: setOnly{&str}},
+		{Name: "empty", Val: (*StringValue)(&str)},
+	}
+	const want = `s = "two words"
+n = 42
+-v = true
+f = <unknown>
+empty = ""
+`
+	var buf bytes.Buffer
+	if err := Dump(&buf, vars); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.Bytes(), want)
+	}
+}
+
+var quoteTests = []struct {
+	in, out string
+}{
+	{"plain", "plain"},
+	{"/usr/bin:/bin", "/usr/bin:/bin"},
+	{"café", "café"},
+	{"", `""`},
+	{"two words", `"two words"`},
+	{" lead", `" lead"`},
+	{"#hash", `"#hash"`},
+	{"a#b", `"a#b"`},
+	{`say "hi"`, `"say \"hi\""`},
+	{"it's", `"it's"`},
+	{"tab\there", `"tab\there"`},
+	{"new\nline", `"new\nline"`},
+	{`back\slash`, `"back\\slash"`},
+	{"a=b", `"a=b"`},
+	{"\x00", `"\x00"`},
+}
+
+func TestQuote(t *testing.T) {
+	for _, test := range quoteTests {
+		if got := Quote(test.in); got != test.out {
+			t.Errorf("Quote(%q): got %s, want %s", test.in, got, test.out)
+		}
+		want := test.out != test.in
+		if got := QuoteNeeded(test.in); got != want {
+			t.Errorf("QuoteNeeded(%q): got %v, want %v",
+				test.in, got, want)
+		}
+		var s string
+		vars := []Var{{Name: "s", Val: (*StringValue)(&s)}}
+		if err := ParseString("s = "+Quote(test.in), "", vars); err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if s != test.in {
+			t.Errorf("%q: parsed as %q", test.in, s)
+		}
+	}
+}